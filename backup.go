@@ -0,0 +1,310 @@
+// Public Domain (-) 2012-2013 The Go DynamoDB Authors.
+// See the Go DynamoDB UNLICENSE file for details.
+
+package dynamodb
+
+import (
+	"encoding/json"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Types of backups, for BackupFilter.
+const (
+	BackupUser   = "USER"
+	BackupSystem = "SYSTEM"
+	BackupAWS    = "AWS_BACKUP"
+	BackupAll    = "ALL"
+)
+
+// BackupDetails describes an on-demand backup. Times are in
+// seconds since the epoch.
+type BackupDetails struct {
+	BackupArn              string
+	BackupName             string
+	BackupSizeBytes        int64
+	BackupStatus           string
+	BackupType             string
+	BackupCreationDateTime float64
+	BackupExpiryDateTime   float64
+}
+
+// BackupSummary is a backup as listed by ListBackups.
+type BackupSummary struct {
+	BackupDetails
+	TableName string
+	TableId   string
+	TableArn  string
+}
+
+// SourceTableDetails describes the table as it was backed up.
+type SourceTableDetails struct {
+	TableName             string
+	TableId               string
+	TableArn              string
+	TableSizeBytes        int64
+	ItemCount             int64
+	KeySchema             []KeyItem
+	TableCreationDateTime float64
+	ProvisionedThroughput ProvisionedThroughput
+	BillingMode           string
+}
+
+// BackupDescription describes a backup and its table.
+type BackupDescription struct {
+	BackupDetails      BackupDetails
+	SourceTableDetails SourceTableDetails
+}
+
+// BackupFilter selects the backups listed by ListBackups. Zero
+// fields don't filter.
+type BackupFilter struct {
+	TableName string
+
+	// BackupType is one of the backup type constants. It
+	// defaults to BackupUser.
+	BackupType string
+
+	// CreatedAfter and CreatedBefore bound the creation time
+	// of the backups.
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+}
+
+// ContinuousBackupsDescription describes the continuous backups
+// of a table and its point in time recovery.
+type ContinuousBackupsDescription struct {
+	ContinuousBackupsStatus        string
+	PointInTimeRecoveryDescription struct {
+		PointInTimeRecoveryStatus  string
+		EarliestRestorableDateTime float64
+		LatestRestorableDateTime   float64
+	}
+}
+
+// RestoreOptions override the settings of the source table of
+// a restore. Zero fields keep those of the source. Global
+// indexes are sent without their throughput when it is zero or
+// BillingMode is BillingPayPerRequest, as with CreateIndex.
+type RestoreOptions struct {
+	BillingMode           string
+	ProvisionedThroughput *ProvisionedThroughput
+	GlobalIndexes         []GlobalIndex
+	LocalIndexes          []Index
+	SSE                   *SSESpecification
+}
+
+// args adds the overrides of o to args.
+func (o *RestoreOptions) args(args Map) {
+	if o == nil {
+		return
+	}
+	if o.BillingMode != "" {
+		args["BillingModeOverride"] = o.BillingMode
+	}
+	if o.ProvisionedThroughput != nil {
+		args["ProvisionedThroughputOverride"] = o.ProvisionedThroughput
+	}
+	if len(o.GlobalIndexes) > 0 {
+		indexes := make([]*GlobalIndexCreate, len(o.GlobalIndexes))
+		for i, index := range o.GlobalIndexes {
+			indexes[i] = &GlobalIndexCreate{Index: index.Index}
+			if index.ProvisionedThroughput != (ProvisionedThroughput{}) && o.BillingMode != BillingPayPerRequest {
+				throughput := index.ProvisionedThroughput
+				indexes[i].ProvisionedThroughput = &throughput
+			}
+		}
+		args["GlobalSecondaryIndexOverride"] = indexes
+	}
+	if len(o.LocalIndexes) > 0 {
+		args["LocalSecondaryIndexOverride"] = o.LocalIndexes
+	}
+	if o.SSE != nil {
+		args["SSESpecificationOverride"] = o.SSE
+	}
+}
+
+// epoch converts t to the seconds since the epoch the API
+// takes.
+func epoch(t time.Time) float64 {
+	return float64(t.UnixNano()) / float64(time.Second)
+}
+
+// CreateBackup creates an on-demand backup of the table called
+// name.
+func (c *Client) CreateBackup(
+	ctx context.Context,
+	name string,
+	backupName string,
+) (*BackupDetails, error) {
+	payload, err := c.Call(ctx, "CreateBackup", Map{
+		"TableName":  name,
+		"BackupName": backupName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		BackupDetails BackupDetails
+	}
+	err = json.Unmarshal(payload, &resp)
+	return &resp.BackupDetails, err
+}
+
+// ListBackups lists the backups selected by filter, which may
+// be nil, following LastEvaluatedBackupArn across pages.
+func (c *Client) ListBackups(ctx context.Context, filter *BackupFilter) ([]BackupSummary, error) {
+	args := Map{}
+	if filter != nil {
+		if filter.TableName != "" {
+			args["TableName"] = filter.TableName
+		}
+		if filter.BackupType != "" {
+			args["BackupType"] = filter.BackupType
+		}
+		if !filter.CreatedAfter.IsZero() {
+			args["TimeRangeLowerBound"] = epoch(filter.CreatedAfter)
+		}
+		if !filter.CreatedBefore.IsZero() {
+			args["TimeRangeUpperBound"] = epoch(filter.CreatedBefore)
+		}
+	}
+	var backups []BackupSummary
+	for {
+		payload, err := c.Call(ctx, "ListBackups", args)
+		if err != nil {
+			return nil, err
+		}
+		var resp struct {
+			BackupSummaries        []BackupSummary
+			LastEvaluatedBackupArn string
+		}
+		if err := json.Unmarshal(payload, &resp); err != nil {
+			return nil, err
+		}
+		backups = append(backups, resp.BackupSummaries...)
+		if resp.LastEvaluatedBackupArn == "" {
+			return backups, nil
+		}
+		args["ExclusiveStartBackupArn"] = resp.LastEvaluatedBackupArn
+	}
+}
+
+// DescribeBackup describes the backup arn.
+func (c *Client) DescribeBackup(ctx context.Context, arn string) (*BackupDescription, error) {
+	return c.backupCall(ctx, "DescribeBackup", arn)
+}
+
+// DeleteBackup deletes the backup arn and returns its
+// description.
+func (c *Client) DeleteBackup(ctx context.Context, arn string) (*BackupDescription, error) {
+	return c.backupCall(ctx, "DeleteBackup", arn)
+}
+
+// backupCall calls method, which returns a BackupDescription,
+// on the backup arn.
+func (c *Client) backupCall(ctx context.Context, method, arn string) (*BackupDescription, error) {
+	payload, err := c.Call(ctx, method, Map{"BackupArn": arn})
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		BackupDescription BackupDescription
+	}
+	err = json.Unmarshal(payload, &resp)
+	return &resp.BackupDescription, err
+}
+
+// RestoreTableFromBackup creates the table called target from
+// the backup arn, with the settings of the backed up table
+// unless opts override them. The table is CREATING until the
+// restore is done, see WaitUntilActive.
+func (c *Client) RestoreTableFromBackup(
+	ctx context.Context,
+	target string,
+	arn string,
+	opts *RestoreOptions,
+) (*TableDesc, error) {
+	args := Map{
+		"TargetTableName": target,
+		"BackupArn":       arn,
+	}
+	opts.args(args)
+	return c.restore(ctx, "RestoreTableFromBackup", target, args)
+}
+
+// RestoreTableToPointInTime creates the table called target
+// from the table called source as it was at the time at, or as
+// late as possible if at is zero. Point in time recovery must
+// be enabled on source, see UpdateContinuousBackups.
+func (c *Client) RestoreTableToPointInTime(
+	ctx context.Context,
+	source string,
+	target string,
+	at time.Time,
+	opts *RestoreOptions,
+) (*TableDesc, error) {
+	args := Map{
+		"SourceTableName": source,
+		"TargetTableName": target,
+	}
+	if at.IsZero() {
+		args["UseLatestRestorableTime"] = true
+	} else {
+		args["RestoreDateTime"] = epoch(at)
+	}
+	opts.args(args)
+	return c.restore(ctx, "RestoreTableToPointInTime", target, args)
+}
+
+// restore calls method restoring the table called target,
+// caching its key schema like CreateTable.
+func (c *Client) restore(ctx context.Context, method, target string, args Map) (*TableDesc, error) {
+	payload, err := c.Call(ctx, method, args)
+	if err != nil {
+		return nil, err
+	}
+	var t TableResponseWrapper
+	if err = json.Unmarshal(payload, &t); err == nil {
+		c.Table(target).cache(&t.TableDescription)
+	}
+	return &t.TableDescription, err
+}
+
+// UpdateContinuousBackups enables or disables point in time
+// recovery of the table called name.
+func (c *Client) UpdateContinuousBackups(
+	ctx context.Context,
+	name string,
+	pointInTimeRecovery bool,
+) (*ContinuousBackupsDescription, error) {
+	payload, err := c.Call(ctx, "UpdateContinuousBackups", Map{
+		"TableName": name,
+		"PointInTimeRecoverySpecification": Map{
+			"PointInTimeRecoveryEnabled": pointInTimeRecovery,
+		},
+	})
+	return continuousBackups(payload, err)
+}
+
+// DescribeContinuousBackups describes the continuous backups
+// and point in time recovery of the table called name.
+func (c *Client) DescribeContinuousBackups(
+	ctx context.Context,
+	name string,
+) (*ContinuousBackupsDescription, error) {
+	payload, err := c.Call(ctx, "DescribeContinuousBackups", Map{"TableName": name})
+	return continuousBackups(payload, err)
+}
+
+func continuousBackups(payload []byte, err error) (*ContinuousBackupsDescription, error) {
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		ContinuousBackupsDescription ContinuousBackupsDescription
+	}
+	err = json.Unmarshal(payload, &resp)
+	return &resp.ContinuousBackupsDescription, err
+}
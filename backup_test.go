@@ -0,0 +1,129 @@
+package dynamodb
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestBackups(t *testing.T) {
+	var calls []string
+	var requests []map[string]interface{}
+	server, client := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var args map[string]interface{}
+		json.Unmarshal(body, &args)
+		target := r.Header.Get("X-Amz-Target")[len("DynamoDB_20120810."):]
+		calls = append(calls, target)
+		requests = append(requests, args)
+		switch target {
+		case "CreateBackup":
+			w.Write([]byte(`{"BackupDetails":{"BackupArn":"arn:1","BackupName":"nightly","BackupStatus":"CREATING","BackupType":"USER","BackupCreationDateTime":1700000000.5}}`))
+		case "ListBackups":
+			if args["ExclusiveStartBackupArn"] == nil {
+				w.Write([]byte(`{"BackupSummaries":[{"BackupArn":"arn:1","TableName":"Users"}],"LastEvaluatedBackupArn":"arn:1"}`))
+			} else {
+				w.Write([]byte(`{"BackupSummaries":[{"BackupArn":"arn:2","TableName":"Users"}]}`))
+			}
+		case "DescribeBackup", "DeleteBackup":
+			w.Write([]byte(`{"BackupDescription":{"BackupDetails":{"BackupArn":"arn:1","BackupStatus":"AVAILABLE"},"SourceTableDetails":{"TableName":"Users","ItemCount":3,"KeySchema":[{"AttributeName":"id","KeyType":"HASH"}]}}}`))
+		case "RestoreTableFromBackup", "RestoreTableToPointInTime":
+			w.Write([]byte(`{"TableDescription":{"TableName":"Restored","TableStatus":"CREATING"}}`))
+		case "UpdateContinuousBackups", "DescribeContinuousBackups":
+			w.Write([]byte(`{"ContinuousBackupsDescription":{"ContinuousBackupsStatus":"ENABLED","PointInTimeRecoveryDescription":{"PointInTimeRecoveryStatus":"ENABLED","LatestRestorableDateTime":1700000100}}}`))
+		}
+	})
+	defer server.Close()
+	ctx := context.Background()
+
+	details, err := client.CreateBackup(ctx, "Users", "nightly")
+	if err != nil || details.BackupArn != "arn:1" || details.BackupCreationDateTime != 1700000000.5 {
+		t.Error("got", details, err)
+	}
+
+	after := time.Unix(1700000000, 0)
+	backups, err := client.ListBackups(ctx, &BackupFilter{TableName: "Users", CreatedAfter: after})
+	if err != nil || len(backups) != 2 || backups[1].BackupArn != "arn:2" || backups[0].TableName != "Users" {
+		t.Error("got", backups, err)
+	}
+	if got := requests[1]["TimeRangeLowerBound"]; got != 1700000000.0 {
+		t.Error("got", got)
+	}
+
+	desc, err := client.DescribeBackup(ctx, "arn:1")
+	if err != nil || desc.BackupDetails.BackupStatus != "AVAILABLE" || desc.SourceTableDetails.ItemCount != 3 {
+		t.Error("got", desc, err)
+	}
+	if _, err := client.DeleteBackup(ctx, "arn:1"); err != nil {
+		t.Error(err)
+	}
+
+	requests = nil
+	table, err := client.RestoreTableFromBackup(ctx, "Restored", "arn:1", &RestoreOptions{BillingMode: BillingPayPerRequest})
+	if err != nil || table.TableStatus != "CREATING" {
+		t.Error("got", table, err)
+	}
+	want := map[string]interface{}{
+		"TargetTableName":     "Restored",
+		"BackupArn":           "arn:1",
+		"BillingModeOverride": "PAY_PER_REQUEST",
+	}
+	if !reflect.DeepEqual(requests[0], want) {
+		t.Error("want", want)
+		t.Error("got ", requests[0])
+	}
+
+	// index overrides leave out throughput unless provisioned
+	requests = nil
+	byEmail := GlobalIndex{
+		Index:                 Index{IndexName: "byEmail", KeySchema: []KeyItem{{"email", "HASH"}}, Projection: Projection{ProjectionType: "KEYS_ONLY"}},
+		ProvisionedThroughput: ProvisionedThroughput{5, 5},
+	}
+	client.RestoreTableFromBackup(ctx, "Restored", "arn:1", &RestoreOptions{BillingMode: BillingPayPerRequest, GlobalIndexes: []GlobalIndex{byEmail}})
+	client.RestoreTableFromBackup(ctx, "Restored", "arn:1", &RestoreOptions{GlobalIndexes: []GlobalIndex{byEmail, {Index: byEmail.Index}}})
+	index := map[string]interface{}{
+		"IndexName":  "byEmail",
+		"KeySchema":  []interface{}{map[string]interface{}{"AttributeName": "email", "KeyType": "HASH"}},
+		"Projection": map[string]interface{}{"NonKeyAttributes": nil, "ProjectionType": "KEYS_ONLY"},
+	}
+	provisioned := map[string]interface{}{
+		"IndexName":             index["IndexName"],
+		"KeySchema":             index["KeySchema"],
+		"Projection":            index["Projection"],
+		"ProvisionedThroughput": map[string]interface{}{"ReadCapacityUnits": 5.0, "WriteCapacityUnits": 5.0},
+	}
+	overrides := []interface{}{
+		[]interface{}{index},
+		[]interface{}{provisioned, index},
+	}
+	for i, want := range overrides {
+		if got := requests[i]["GlobalSecondaryIndexOverride"]; !reflect.DeepEqual(got, want) {
+			t.Error("want", want)
+			t.Error("got ", got)
+		}
+	}
+
+	// restores default to the latest restorable time
+	requests = nil
+	client.RestoreTableToPointInTime(ctx, "Users", "Restored", time.Time{}, nil)
+	client.RestoreTableToPointInTime(ctx, "Users", "Restored", after, nil)
+	if requests[0]["UseLatestRestorableTime"] != true || requests[1]["RestoreDateTime"] != 1700000000.0 {
+		t.Error("got", requests)
+	}
+
+	backup, err := client.UpdateContinuousBackups(ctx, "Users", true)
+	if err != nil || backup.PointInTimeRecoveryDescription.LatestRestorableDateTime != 1700000100 {
+		t.Error("got", backup, err)
+	}
+	if _, err := client.DescribeContinuousBackups(ctx, "Users"); err != nil {
+		t.Error(err)
+	}
+	if got := len(calls); got != 12 {
+		t.Error("calls", calls)
+	}
+}
@@ -0,0 +1,81 @@
+// Public Domain (-) 2012-2013 The Go DynamoDB Authors.
+// See the Go DynamoDB UNLICENSE file for details.
+
+package dynamodb
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"sync"
+)
+
+// maxPooledBuffer is the capacity above which buffers are left
+// to the garbage collector rather than pooled, so that a single
+// large batch doesn't pin its memory forever.
+const maxPooledBuffer = 64 << 10
+
+var buffers = sync.Pool{
+	New: func() interface{} { return &bytes.Buffer{} },
+}
+
+// getBuffer takes an empty buffer from the pool.
+func getBuffer() *bytes.Buffer {
+	buf := buffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// putBuffer returns buf to the pool. Nothing may refer to its
+// contents afterwards.
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledBuffer {
+		buffers.Put(buf)
+	}
+}
+
+// writeString writes s to buf as a JSON string.
+func writeString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	toJSON(s, buf)
+	buf.WriteByte('"')
+}
+
+// writeHex writes the hex encoding of a hash or signature to buf.
+func writeHex(buf *bytes.Buffer, b []byte) {
+	var tmp [2 * 64]byte
+	n := hex.Encode(tmp[:], b)
+	buf.Write(tmp[:n])
+}
+
+// requestBody is the body of a request, reading its payload
+// until released. Payloads are built in pooled buffers which
+// are reused once the call returns, while a transport may
+// still hold on to the body, e.g. after a cancelled request;
+// reads after the release fail instead of sending whatever
+// the buffer holds by then.
+type requestBody struct {
+	mu       sync.Mutex
+	r        bytes.Reader
+	released bool
+}
+
+func (b *requestBody) Read(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.released {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return b.r.Read(p)
+}
+
+func (b *requestBody) Close() error {
+	return nil
+}
+
+// release stops the body from reading its payload.
+func (b *requestBody) release() {
+	b.mu.Lock()
+	b.released = true
+	b.mu.Unlock()
+}
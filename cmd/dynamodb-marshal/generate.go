@@ -0,0 +1,850 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+type fieldInfo struct {
+	dbName  string
+	keyType string
+	name    string
+	typ     *typeInfo
+}
+
+// typeInfo describes how values of a Go type are encoded.
+type typeInfo struct {
+	// kind is a key in kindMap for scalars and sets, or one of
+	// "list", "map", "ptr" and "struct".
+	kind string
+	typ  types.Type
+
+	// named is set when typ is a named type whose underlying
+	// type is kind, which requires conversions.
+	named bool
+
+	// elem describes the elements of sets, lists, maps and
+	// pointers, and key the keys of maps.
+	elem *typeInfo
+	key  *typeInfo
+}
+
+type model struct {
+	fields []fieldInfo
+	name   string
+	file   string
+	pos    token.Pos
+}
+
+// byPosition sorts models in the order they are declared, so
+// that file and package mode generate the same code.
+type byPosition []*model
+
+func (m byPosition) Len() int           { return len(m) }
+func (m byPosition) Less(i, j int) bool { return m[i].pos < m[j].pos }
+func (m byPosition) Swap(i, j int)      { m[i], m[j] = m[j], m[i] }
+
+var header = `// Code generated by dynamodb-marshal. DO NOT EDIT.
+
+package `
+
+var kindMap = map[string]string{
+	"[]byte":   "B",
+	"bool":     "N",
+	"int":      "N",
+	"int64":    "N",
+	"string":   "S",
+	"time":     "N",
+	"ttl":      "N",
+	"uint":     "N",
+	"uint64":   "N",
+	"[][]byte": "BS",
+	"[]bool":   "NS",
+	"[]int":    "NS",
+	"[]int64":  "NS",
+	"[]string": "SS",
+	"[]uint":   "NS",
+	"[]uint64": "NS",
+
+	"ttlDuration": "N",
+}
+
+// generator accumulates the body of a generated file along
+// with the imports it needs.
+type generator struct {
+	buf     bytes.Buffer
+	imports map[string]bool
+	pkg     *types.Package
+
+	// literal and lead hold a string yet to be written, so
+	// that consecutive literals are written at once.
+	literal string
+	lead    string
+}
+
+func newGenerator(pkg *types.Package) *generator {
+	return &generator{imports: map[string]bool{}, pkg: pkg}
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	if g.literal != "" {
+		fmt.Fprintf(&g.buf, "%sbuf.WriteString(`%s`)\n", g.lead, g.literal)
+		g.literal = ""
+	}
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// writeString writes a statement writing s to the buffer,
+// merged with any literal strings written right before it.
+func (g *generator) writeString(lead, s string) {
+	if g.literal != "" && g.lead != lead {
+		g.printf("")
+	}
+	g.literal += s
+	g.lead = lead
+}
+
+func (g *generator) use(path string) {
+	g.imports[path] = true
+}
+
+// typeString returns the Go expression for t in the generated
+// file, importing the packages it refers to.
+func (g *generator) typeString(t types.Type) string {
+	return types.TypeString(t, func(other *types.Package) string {
+		if other == g.pkg {
+			return ""
+		}
+		g.use(other.Path())
+		return other.Name()
+	})
+}
+
+// convert returns expr converted from the type described by t
+// to its underlying type kind, if required.
+func (g *generator) convert(t *typeInfo, expr string) string {
+	if t.named {
+		return t.kind + "(" + expr + ")"
+	}
+	return expr
+}
+
+// file returns the complete source of the generated file for
+// package pkg.
+func (g *generator) file(pkg string) []byte {
+	out := &bytes.Buffer{}
+	out.WriteString(header)
+	out.WriteString(pkg)
+	out.WriteString("\n\n")
+	if len(g.imports) > 0 {
+		paths := make([]string, 0, len(g.imports))
+		for path := range g.imports {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		out.WriteString("import (\n")
+		for _, path := range paths {
+			fmt.Fprintf(out, "\t%q\n", path)
+		}
+		out.WriteString(")\n\n")
+	}
+	out.Write(g.buf.Bytes())
+	return out.Bytes()
+}
+
+// model writes the methods of the Item, KeyEncoder,
+// ExpectedEncoder and ItemDecoder interfaces for m.
+func (g *generator) model(m *model) {
+	g.use("bytes")
+	ref := strings.ToLower(string(m.name[0]))
+	g.encode(ref, m.name, "Encode", m.fields, false)
+	var keys []fieldInfo
+	for _, field := range m.fields {
+		if field.keyType != "" {
+			keys = append(keys, field)
+		}
+	}
+	if len(keys) > 0 {
+		g.encode(ref, m.name, "EncodeKey", keys, false)
+	}
+	g.encode(ref, m.name, "EncodeExpected", m.fields, true)
+	g.decode(ref, m)
+	g.decodeItem(ref, m)
+}
+
+// encode writes a method encoding fields the way the
+// reflection-based encoder does, optionally in the shape of
+// the Expected parameter of a conditional write.
+func (g *generator) encode(ref, name, method string, fields []fieldInfo, expected bool) {
+	g.printf("func (%s *%s) %s(buf *bytes.Buffer) {\n", ref, name, method)
+	if len(fields) == 0 {
+		// an empty item, which may be nested in another
+		g.writeString("\t", "{}")
+		g.printf("}\n\n")
+		return
+	}
+	last := len(fields) - 1
+	close := `{"`
+	for idx, field := range fields {
+		comma := ","
+		if idx == last {
+			comma = ""
+		}
+		selector := fmt.Sprintf("%s.%s", ref, field.name)
+		dbKind, ok := kindMap[field.typ.kind]
+		if !ok {
+			// nested attributes are written as a whole by value
+			if expected {
+				g.writeString("\t", fmt.Sprintf(`%s%s":{"Value":`, close, field.dbName))
+				close = fmt.Sprintf(`}%s"`, comma)
+			} else {
+				g.writeString("\t", fmt.Sprintf(`%s%s":`, close, field.dbName))
+				close = fmt.Sprintf(`%s"`, comma)
+			}
+			g.value("\t", field.typ, selector, 0)
+			continue
+		}
+		prefix := `"`
+		suffix := `"`
+		if len(dbKind) == 2 {
+			prefix = "["
+			suffix = "]"
+		}
+		if expected {
+			g.writeString("\t", fmt.Sprintf(`%s%s":{"Value":{"%s":%s`, close, field.dbName, dbKind, prefix))
+			close = fmt.Sprintf(`%s}}%s"`, suffix, comma)
+		} else {
+			g.writeString("\t", fmt.Sprintf(`%s%s":{"%s":%s`, close, field.dbName, dbKind, prefix))
+			close = fmt.Sprintf(`%s}%s"`, suffix, comma)
+		}
+		if len(dbKind) == 2 {
+			g.set("\t", field.typ, selector, 0)
+		} else {
+			g.write("\t", field.typ, selector)
+		}
+	}
+	g.writeString("\t", close[:len(close)-1]+"}")
+	g.printf("}\n\n")
+}
+
+// vars returns the names of the loop variables used at depth.
+func vars(depth int, names ...string) []string {
+	if depth == 0 {
+		return names
+	}
+	suffixed := make([]string, len(names))
+	for i, name := range names {
+		suffixed[i] = fmt.Sprintf("%s%d", name, depth)
+	}
+	return suffixed
+}
+
+// set writes the elements of the set selector, quoted and
+// separated by commas.
+func (g *generator) set(lead string, t *typeInfo, selector string, depth int) {
+	v := vars(depth, "idx", "elem")
+	g.printf("%sfor %s, %s := range %s {\n", lead, v[0], v[1], selector)
+	g.printf("%s\tbuf.WriteByte('\"')\n", lead)
+	g.write(lead+"\t", t.elem, v[1])
+	g.printf("%s\tif %s == len(%s)-1 {\n", lead, v[0], selector)
+	g.printf("%s\t\tbuf.WriteByte('\"')\n", lead)
+	g.printf("%s\t} else {\n", lead)
+	g.printf("%s\t\tbuf.WriteString(`\",`)\n", lead)
+	g.printf("%s\t}\n", lead)
+	g.printf("%s}\n", lead)
+}
+
+// deref returns the expression for the value of type t
+// pointed to by expr.
+func deref(t *typeInfo, expr string) string {
+	switch t.kind {
+	case "struct":
+		return expr
+	case "map", "time":
+		return "(*" + expr + ")"
+	}
+	return "*" + expr
+}
+
+// value writes the complete attribute value of expr, e.g.
+// {"S":"..."}, using the Encode method of nested structs.
+func (g *generator) value(lead string, t *typeInfo, expr string, depth int) {
+	switch t.kind {
+	case "struct":
+		g.writeString(lead, `{"M":`)
+		g.printf("%s%s.Encode(buf)\n", lead, expr)
+		g.writeString(lead, `}`)
+	case "ptr":
+		g.printf("%sif %s == nil {\n", lead, expr)
+		g.writeString(lead+"\t", `{"NULL":true}`)
+		g.printf("%s} else {\n", lead)
+		g.value(lead+"\t", t.elem, deref(t.elem, expr), depth)
+		g.printf("%s}\n", lead)
+	case "list", "map":
+		// nil lists and maps are null like nil pointers
+		g.printf("%sif %s == nil {\n", lead, expr)
+		g.writeString(lead+"\t", `{"NULL":true}`)
+		g.printf("%s} else {\n", lead)
+		g.collection(lead+"\t", t, expr, depth)
+		g.printf("%s}\n", lead)
+	default:
+		dbKind := kindMap[t.kind]
+		if len(dbKind) == 2 {
+			g.writeString(lead, fmt.Sprintf(`{"%s":[`, dbKind))
+			g.set(lead, t, expr, depth)
+			g.writeString(lead, `]}`)
+		} else {
+			g.writeString(lead, fmt.Sprintf(`{"%s":"`, dbKind))
+			g.write(lead, t, expr)
+			g.writeString(lead, `"}`)
+		}
+	}
+}
+
+// collection writes the list or map attribute value of expr.
+func (g *generator) collection(lead string, t *typeInfo, expr string, depth int) {
+	if t.kind == "list" {
+		v := vars(depth, "idx", "elem")
+		g.writeString(lead, `{"L":[`)
+		g.printf("%sfor %s, %s := range %s {\n", lead, v[0], v[1], expr)
+		g.printf("%s\tif %s > 0 {\n", lead, v[0])
+		g.printf("%s\t\tbuf.WriteByte(',')\n", lead)
+		g.printf("%s\t}\n", lead)
+		g.value(lead+"\t", t.elem, v[1], depth+1)
+		g.printf("%s}\n", lead)
+		g.writeString(lead, `]}`)
+		return
+	}
+	// the comma following the last entry is removed
+	v := vars(depth, "key", "elem")
+	g.writeString(lead, `{"M":{`)
+	g.printf("%sfor %s, %s := range %s {\n", lead, v[0], v[1], expr)
+	g.printf("%s\tbuf.WriteByte('\"')\n", lead)
+	g.printf("%s\ttoJSON(%s, buf)\n", lead, g.convert(t.key, v[0]))
+	g.writeString(lead+"\t", `":`)
+	g.value(lead+"\t", t.elem, v[1], depth+1)
+	g.writeString(lead+"\t", ",")
+	g.printf("%s}\n", lead)
+	g.printf("%sif len(%s) > 0 {\n", lead, expr)
+	g.printf("%s\tbuf.Truncate(buf.Len() - 1)\n", lead)
+	g.printf("%s}\n", lead)
+	g.writeString(lead, `}}`)
+}
+
+func (g *generator) decode(ref string, m *model) {
+	g.printf("func (%s *%s) Decode(data map[string]map[string]interface{}) {\n", ref, m.name)
+	for _, field := range m.fields {
+		dbKind, ok := kindMap[field.typ.kind]
+		selector := fmt.Sprintf("%s.%s", ref, field.name)
+		switch {
+		case !ok:
+			g.printf("\tif av, ok := data[\"%s\"]; ok {\n", field.dbName)
+			g.readValue("\t\t", field.typ, "av", selector, 1)
+		case len(dbKind) == 2:
+			g.printf("\tif vals, ok := data[\"%s\"][\"%s\"].([]interface{}); ok {\n", field.dbName, dbKind)
+			g.printf("\t\tfor _, sval := range vals {\n")
+			g.printf("\t\t\tval := sval.(string)\n")
+			g.readMulti("\t\t\t", field.typ, selector)
+			g.printf("\t\t}\n")
+		default:
+			g.printf("\tif val, ok := data[\"%s\"][\"%s\"].(string); ok {\n", field.dbName, dbKind)
+			g.read("\t\t", field.typ, selector)
+		}
+		g.printf("\t}\n")
+	}
+	g.printf("}\n\n")
+}
+
+// readValue writes code decoding the attribute value av into
+// target.
+func (g *generator) readValue(lead string, t *typeInfo, av, target string, depth int) {
+	switch t.kind {
+	case "struct":
+		m := vars(depth, "m")[0]
+		g.printf("%sif %s, ok := %s[\"M\"].(map[string]interface{}); ok {\n", lead, m, av)
+		g.printf("%s\t%s.Decode(toItem(%s))\n", lead, target, m)
+		g.printf("%s}\n", lead)
+	case "ptr":
+		p := vars(depth, "p")[0]
+		g.printf("%sif _, ok := %s[\"NULL\"]; ok {\n", lead, av)
+		g.printf("%s\t%s = nil\n", lead, target)
+		g.printf("%s} else {\n", lead)
+		g.printf("%s\t%s := new(%s)\n", lead, p, g.typeString(t.elem.typ))
+		g.readValue(lead+"\t", t.elem, av, deref(t.elem, p), depth+1)
+		g.printf("%s\t%s = %s\n", lead, target, p)
+		g.printf("%s}\n", lead)
+	case "list":
+		v := vars(depth, "l", "raw", "av", "elem")
+		g.printf("%sif _, ok := %s[\"NULL\"]; ok {\n", lead, av)
+		g.printf("%s\t%s = nil\n", lead, target)
+		g.printf("%s} else if %s, ok := %s[\"L\"].([]interface{}); ok {\n", lead, v[0], av)
+		g.printf("%s\t%s = make(%s, 0, len(%s))\n", lead, target, g.typeString(t.typ), v[0])
+		g.printf("%s\tfor _, %s := range %s {\n", lead, v[1], v[0])
+		g.printf("%s\t\t%s, _ := %s.(map[string]interface{})\n", lead, v[2], v[1])
+		g.printf("%s\t\tvar %s %s\n", lead, v[3], g.typeString(t.elem.typ))
+		g.readValue(lead+"\t\t", t.elem, v[2], v[3], depth+1)
+		g.printf("%s\t\t%s = append(%s, %s)\n", lead, target, target, v[3])
+		g.printf("%s\t}\n", lead)
+		g.printf("%s}\n", lead)
+	case "map":
+		v := vars(depth, "m", "key", "raw", "av", "elem")
+		key := v[1]
+		if t.key.named {
+			key = g.typeString(t.key.typ) + "(" + key + ")"
+		}
+		g.printf("%sif _, ok := %s[\"NULL\"]; ok {\n", lead, av)
+		g.printf("%s\t%s = nil\n", lead, target)
+		g.printf("%s} else if %s, ok := %s[\"M\"].(map[string]interface{}); ok {\n", lead, v[0], av)
+		g.printf("%s\t%s = make(%s, len(%s))\n", lead, target, g.typeString(t.typ), v[0])
+		g.printf("%s\tfor %s, %s := range %s {\n", lead, v[1], v[2], v[0])
+		g.printf("%s\t\t%s, _ := %s.(map[string]interface{})\n", lead, v[3], v[2])
+		g.printf("%s\t\tvar %s %s\n", lead, v[4], g.typeString(t.elem.typ))
+		g.readValue(lead+"\t\t", t.elem, v[3], v[4], depth+1)
+		g.printf("%s\t\t%s[%s] = %s\n", lead, target, key, v[4])
+		g.printf("%s\t}\n", lead)
+		g.printf("%s}\n", lead)
+	default:
+		dbKind := kindMap[t.kind]
+		if len(dbKind) == 2 {
+			g.printf("%sif vals, ok := %s[\"%s\"].([]interface{}); ok {\n", lead, av, dbKind)
+			g.printf("%s\tfor _, sval := range vals {\n", lead)
+			g.printf("%s\t\tval := sval.(string)\n", lead)
+			g.readMulti(lead+"\t\t", t, target)
+			g.printf("%s\t}\n", lead)
+		} else {
+			g.printf("%sif val, ok := %s[\"%s\"].(string); ok {\n", lead, av, dbKind)
+			g.read(lead+"\t", t, target)
+		}
+		g.printf("%s}\n", lead)
+	}
+}
+
+// decodeItem writes the DecodeItem method of the ItemDecoder
+// interface, which decodes m straight from a response.
+func (g *generator) decodeItem(ref string, m *model) {
+	g.use(dynamodbPath)
+	g.printf("func (%s *%s) DecodeItem(dec *dynamodb.Decoder) {\n", ref, m.name)
+	g.printf("\tfor dec.Next() {\n")
+	g.printf("\t\tswitch string(dec.Name()) {\n")
+	for _, field := range m.fields {
+		g.printf("\t\tcase \"%s\":\n", field.dbName)
+		g.stream("\t\t\t", field.typ, fmt.Sprintf("%s.%s", ref, field.name), 1)
+	}
+	g.printf("\t\tdefault:\n")
+	g.printf("\t\t\tdec.Skip()\n")
+	g.printf("\t\t}\n")
+	g.printf("\t}\n")
+	g.printf("}\n\n")
+}
+
+// stream writes code reading the attribute value being read by
+// dec into target.
+func (g *generator) stream(lead string, t *typeInfo, target string, depth int) {
+	switch t.kind {
+	case "struct":
+		addr := "&" + target
+		if strings.HasPrefix(target, "*") {
+			addr = target[1:]
+		}
+		g.printf("%sif dec.Attr(\"M\") {\n", lead)
+		g.printf("%s\tdec.Decode(%s)\n", lead, addr)
+		g.printf("%s\tdec.End()\n", lead)
+		g.printf("%s}\n", lead)
+	case "ptr":
+		p := vars(depth, "p")[0]
+		elem := "*" + p
+		if t.elem.kind == "map" {
+			elem = "(*" + p + ")"
+		}
+		g.printf("%sif dec.Null() {\n", lead)
+		g.printf("%s\t%s = nil\n", lead, target)
+		g.printf("%s} else {\n", lead)
+		g.printf("%s\t%s := new(%s)\n", lead, p, g.typeString(t.elem.typ))
+		g.stream(lead+"\t", t.elem, elem, depth+1)
+		g.printf("%s\t%s = %s\n", lead, target, p)
+		g.printf("%s}\n", lead)
+	case "list":
+		elem := vars(depth, "elem")[0]
+		g.printf("%sif dec.Null() {\n", lead)
+		g.printf("%s\t%s = nil\n", lead, target)
+		g.printf("%s} else if dec.Attr(\"L\") {\n", lead)
+		g.printf("%s\t%s = make(%s, 0)\n", lead, target, g.typeString(t.typ))
+		g.printf("%s\tfor dec.NextElem() {\n", lead)
+		g.printf("%s\t\tvar %s %s\n", lead, elem, g.typeString(t.elem.typ))
+		g.stream(lead+"\t\t", t.elem, elem, depth+1)
+		g.printf("%s\t\t%s = append(%s, %s)\n", lead, target, target, elem)
+		g.printf("%s\t}\n", lead)
+		g.printf("%s\tdec.End()\n", lead)
+		g.printf("%s}\n", lead)
+	case "map":
+		v := vars(depth, "key", "elem")
+		key := "string(dec.Name())"
+		if t.key.named {
+			key = g.typeString(t.key.typ) + "(dec.Name())"
+		}
+		g.printf("%sif dec.Null() {\n", lead)
+		g.printf("%s\t%s = nil\n", lead, target)
+		g.printf("%s} else if dec.Attr(\"M\") {\n", lead)
+		g.printf("%s\t%s = make(%s)\n", lead, target, g.typeString(t.typ))
+		g.printf("%s\tfor dec.Next() {\n", lead)
+		g.printf("%s\t\t%s := %s\n", lead, v[0], key)
+		g.printf("%s\t\tvar %s %s\n", lead, v[1], g.typeString(t.elem.typ))
+		g.stream(lead+"\t\t", t.elem, v[1], depth+1)
+		g.printf("%s\t\t%s[%s] = %s\n", lead, target, v[0], v[1])
+		g.printf("%s\t}\n", lead)
+		g.printf("%s\tdec.End()\n", lead)
+		g.printf("%s}\n", lead)
+	default:
+		dbKind := kindMap[t.kind]
+		g.printf("%sif dec.Attr(\"%s\") {\n", lead, dbKind)
+		if len(dbKind) == 2 {
+			g.printf("%s\tfor dec.NextElem() {\n", lead)
+			g.printf("%s\t\t%s = append(%s, %s)\n", lead, target, target, g.streamExpr(t.elem))
+			g.printf("%s\t}\n", lead)
+		} else {
+			g.printf("%s\t%s = %s\n", lead, target, g.streamExpr(t))
+		}
+		g.printf("%s\tdec.End()\n", lead)
+		g.printf("%s}\n", lead)
+	}
+}
+
+// streamExpr returns the expression reading a value of the
+// scalar type t with dec.
+func (g *generator) streamExpr(t *typeInfo) string {
+	var expr string
+	switch t.kind {
+	case "[]byte":
+		expr = "dec.Bytes()"
+	case "bool":
+		expr = "dec.Bool()"
+	case "string":
+		expr = "dec.String()"
+	case "int", "int64":
+		expr = "dec.Int()"
+	case "uint", "uint64":
+		expr = "dec.Uint()"
+	case "time":
+		g.use("time")
+		return "time.Unix(0, dec.Int()).UTC()"
+	case "ttl":
+		g.use(dynamodbPath)
+		return "dynamodb.DecodeTTL(dec.Int())"
+	case "ttlDuration":
+		g.use(dynamodbPath)
+		return "dynamodb.DecodeTTLDuration(dec.Int())"
+	}
+	switch {
+	case t.named:
+		return g.typeString(t.typ) + "(" + expr + ")"
+	case t.kind == "int" || t.kind == "uint":
+		return t.kind + "(" + expr + ")"
+	}
+	return expr
+}
+
+// parse writes code assigning the result of the two-valued
+// expr to selector, converting it to the named type of t.
+func (g *generator) parse(lead string, t *typeInfo, selector, expr string) {
+	if t.named {
+		g.printf("%stmp, _ := %s\n", lead, expr)
+		g.printf("%s%s = %s(tmp)\n", lead, selector, g.typeString(t.typ))
+	} else {
+		g.printf("%s%s, _ = %s\n", lead, selector, expr)
+	}
+}
+
+// conversion returns the type a parsed value is converted to
+// before assigning it to a value of type t.
+func (g *generator) conversion(t *typeInfo) string {
+	if t.named {
+		return g.typeString(t.typ)
+	}
+	return t.kind
+}
+
+func (g *generator) read(lead string, t *typeInfo, selector string) {
+	switch t.kind {
+	case "[]byte":
+		g.use("encoding/base64")
+		g.parse(lead, t, selector, "base64.StdEncoding.DecodeString(val)")
+	case "bool":
+		g.printf("%sif val == \"1\" {\n", lead)
+		g.printf("%s\t%s = true\n", lead, selector)
+		g.printf("%s} else if val == \"0\" {\n", lead)
+		g.printf("%s\t%s = false\n", lead, selector)
+		g.printf("%s}\n", lead)
+	case "string":
+		if t.named {
+			g.printf("%s%s = %s(val)\n", lead, selector, g.typeString(t.typ))
+		} else {
+			g.printf("%s%s = val\n", lead, selector)
+		}
+	case "int":
+		g.use("strconv")
+		g.printf("%stmp, _ := strconv.ParseInt(val, 10, 64)\n", lead)
+		g.printf("%s%s = %s(tmp)\n", lead, selector, g.conversion(t))
+	case "int64":
+		g.use("strconv")
+		g.parse(lead, t, selector, "strconv.ParseInt(val, 10, 64)")
+	case "uint":
+		g.use("strconv")
+		g.printf("%stmp, _ := strconv.ParseUint(val, 10, 64)\n", lead)
+		g.printf("%s%s = %s(tmp)\n", lead, selector, g.conversion(t))
+	case "uint64":
+		g.use("strconv")
+		g.parse(lead, t, selector, "strconv.ParseUint(val, 10, 64)")
+	case "time":
+		g.use("strconv")
+		g.use("time")
+		g.printf("%stmp, _ := strconv.ParseInt(val, 10, 64)\n", lead)
+		g.printf("%s%s = time.Unix(0, tmp).UTC()\n", lead, selector)
+	case "ttl", "ttlDuration":
+		g.use("strconv")
+		g.use(dynamodbPath)
+		g.printf("%stmp, _ := strconv.ParseInt(val, 10, 64)\n", lead)
+		g.printf("%s%s = dynamodb.Decode%s(tmp)\n", lead, selector, ttlFunc[t.kind])
+	}
+}
+
+func (g *generator) readMulti(lead string, t *typeInfo, selector string) {
+	elem := t.elem
+	appendf := func(value string) {
+		if elem.named {
+			value = g.typeString(elem.typ) + "(" + value + ")"
+		}
+		g.printf("%s%s = append(%s, %s)\n", lead, selector, selector, value)
+	}
+	switch t.kind {
+	case "[][]byte":
+		g.use("encoding/base64")
+		g.printf("%stmp, _ := base64.StdEncoding.DecodeString(val)\n", lead)
+		appendf("tmp")
+	case "[]bool":
+		g.printf("%sif val == \"1\" {\n", lead)
+		g.printf("%s\t%s = append(%s, true)\n", lead, selector, selector)
+		g.printf("%s} else if val == \"0\" {\n", lead)
+		g.printf("%s\t%s = append(%s, false)\n", lead, selector, selector)
+		g.printf("%s}\n", lead)
+	case "[]string":
+		appendf("val")
+	case "[]int":
+		g.use("strconv")
+		g.printf("%stmp, _ := strconv.ParseInt(val, 10, 64)\n", lead)
+		if elem.named {
+			appendf("tmp")
+		} else {
+			appendf("int(tmp)")
+		}
+	case "[]int64":
+		g.use("strconv")
+		g.printf("%stmp, _ := strconv.ParseInt(val, 10, 64)\n", lead)
+		appendf("tmp")
+	case "[]uint":
+		g.use("strconv")
+		g.printf("%stmp, _ := strconv.ParseUint(val, 10, 64)\n", lead)
+		if elem.named {
+			appendf("tmp")
+		} else {
+			appendf("uint(tmp)")
+		}
+	case "[]uint64":
+		g.use("strconv")
+		g.printf("%stmp, _ := strconv.ParseUint(val, 10, 64)\n", lead)
+		appendf("tmp")
+	}
+}
+
+func (g *generator) write(lead string, t *typeInfo, selector string) {
+	selector = g.convert(t, selector)
+	switch t.kind {
+	case "[]byte":
+		g.use("encoding/base64")
+		g.printf("%sbuf.WriteString(base64.StdEncoding.EncodeToString(%s))\n", lead, selector)
+	case "bool":
+		g.printf("%sif %s {\n", lead, selector)
+		g.printf("%s\tbuf.WriteByte('1')\n", lead)
+		g.printf("%s} else {\n", lead)
+		g.printf("%s\tbuf.WriteByte('0')\n", lead)
+		g.printf("%s}\n", lead)
+	case "string":
+		g.printf("%stoJSON(%s, buf)\n", lead, selector)
+	case "int":
+		g.use("strconv")
+		g.printf("%sbuf.WriteString(strconv.FormatInt(int64(%s), 10))\n", lead, selector)
+	case "int64":
+		g.use("strconv")
+		g.printf("%sbuf.WriteString(strconv.FormatInt(%s, 10))\n", lead, selector)
+	case "uint":
+		g.use("strconv")
+		g.printf("%sbuf.WriteString(strconv.FormatUint(uint64(%s), 10))\n", lead, selector)
+	case "uint64":
+		g.use("strconv")
+		g.printf("%sbuf.WriteString(strconv.FormatUint(%s, 10))\n", lead, selector)
+	case "time":
+		g.use("strconv")
+		g.printf("%sbuf.WriteString(strconv.FormatInt(%s.UnixNano(), 10))\n", lead, selector)
+	case "ttl", "ttlDuration":
+		g.use("strconv")
+		g.use(dynamodbPath)
+		g.printf("%sbuf.WriteString(strconv.FormatInt(dynamodb.Encode%s(%s), 10))\n", lead, ttlFunc[t.kind], selector)
+	}
+}
+
+// ttlFunc names the dynamodb functions converting the kinds of
+// ttl fields, e.g. EncodeTTL.
+var ttlFunc = map[string]string{
+	"ttl":         "TTL",
+	"ttlDuration": "TTLDuration",
+}
+
+// dynamodbPath is the import path of the dynamodb package.
+const dynamodbPath = "github.com/groupme/dynamodb-1"
+
+// contextPath is the import path of the context package taken
+// by the methods of the dynamodb package.
+const contextPath = "golang.org/x/net/context"
+
+// repository writes a typed wrapper of dynamodb.Table for m if
+// it has a HASH key, taking and returning m rather than
+// interface{} values.
+func (g *generator) repository(m *model) {
+	var hash, rng *fieldInfo
+	for i, field := range m.fields {
+		switch field.keyType {
+		case "HASH":
+			hash = &m.fields[i]
+		case "RANGE":
+			rng = &m.fields[i]
+		}
+	}
+	if hash == nil {
+		return
+	}
+	g.use("bytes")
+	g.use(contextPath)
+	g.use(dynamodbPath)
+	name := m.name + "Table"
+	params := "hashKey " + g.typeString(hash.typ.typ)
+	key := hash.name + ": hashKey"
+	if rng != nil {
+		params += ", rangeKey " + g.typeString(rng.typ.typ)
+		key += ", " + rng.name + ": rangeKey"
+	}
+
+	g.printf("// %s accesses a table of %s items.\n", name, m.name)
+	g.printf("type %s struct {\n\ttable *dynamodb.Table\n}\n\n", name)
+	g.printf("// New%s wraps table.\n", name)
+	g.printf("func New%s(table *dynamodb.Table) *%s {\n", name, name)
+	g.printf("\treturn &%s{table: table}\n}\n\n", name)
+
+	g.printf("// Get fetches the item with the given key, with a strongly\n")
+	g.printf("// consistent read if consistent is set.\n")
+	g.printf("func (t *%s) Get(ctx context.Context, %s, consistent bool) (*%s, error) {\n", name, params, m.name)
+	g.printf("\titem := &%s{%s}\n", m.name, key)
+	g.printf("\tif err := t.table.Get(ctx, item, consistent); err != nil {\n")
+	g.printf("\t\treturn nil, err\n\t}\n")
+	g.printf("\treturn item, nil\n}\n\n")
+
+	g.printf("// Put puts item.\n")
+	g.printf("func (t *%s) Put(ctx context.Context, item *%s) error {\n", name, m.name)
+	g.printf("\treturn t.table.Put(ctx, item)\n}\n\n")
+
+	g.printf("// Delete deletes the item with the given key.\n")
+	g.printf("func (t *%s) Delete(ctx context.Context, %s) error {\n", name, params)
+	g.printf("\treturn t.table.Delete(ctx, &%s{%s})\n}\n\n", m.name, key)
+
+	g.printf("// Query returns the items with the given hash key.\n")
+	g.printf("func (t *%s) Query(ctx context.Context, hashKey %s) ([]*%s, error) {\n", name, g.typeString(hash.typ.typ), m.name)
+	g.printf("\tbuf := &bytes.Buffer{}\n")
+	g.value("\t", hash.typ, "hashKey", 0)
+	g.printf("\tvar result []*%s\n", m.name)
+	g.printf("\terr := t.table.Query().Where(\"%s\", dynamodb.EQ, buf.Bytes()).Decode(ctx, false, func(dec *dynamodb.Decoder) {\n", hash.dbName)
+	g.printf("\t\titem := &%s{}\n", m.name)
+	g.printf("\t\titem.DecodeItem(dec)\n")
+	g.printf("\t\tresult = append(result, item)\n\t})\n")
+	g.printf("\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+	g.printf("\treturn result, nil\n}\n\n")
+}
+
+// helpersFile is the name of the file holding the functions
+// shared by all the generated code in a package.
+const helpersFile = "dynamodb_marshal_helpers.go"
+
+// helpers writes the functions shared by the generated code.
+func (g *generator) helpers() {
+	g.use("bytes")
+	g.use("unicode/utf8")
+	g.buf.WriteString(jsonSupport)
+	g.buf.WriteString(itemSupport)
+}
+
+var itemSupport = `
+// toItem converts a decoded map attribute to the form taken by
+// Decode.
+func toItem(m map[string]interface{}) map[string]map[string]interface{} {
+	item := make(map[string]map[string]interface{}, len(m))
+	for name, raw := range m {
+		if av, ok := raw.(map[string]interface{}); ok {
+			item[name] = av
+		}
+	}
+	return item
+}
+`
+
+var jsonSupport = `// Adapted from the encoding/json package in the standard
+// library.
+const jsonHex = "0123456789abcdef"
+
+func toJSON(s string, buf *bytes.Buffer) {
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if 0x20 <= b && b != '\\' && b != '"' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+			if start < i {
+				buf.WriteString(s[start:i])
+			}
+			switch b {
+			case '\\', '"':
+				buf.WriteByte('\\')
+				buf.WriteByte(b)
+			case '\n':
+				buf.WriteByte('\\')
+				buf.WriteByte('n')
+			case '\r':
+				buf.WriteByte('\\')
+				buf.WriteByte('r')
+			default:
+				buf.WriteString("\\u00")
+				buf.WriteByte(jsonHex[b>>4])
+				buf.WriteByte(jsonHex[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		c, size := utf8.DecodeRuneInString(s[i:])
+		if c == utf8.RuneError && size == 1 {
+			if start < i {
+				buf.WriteString(s[start:i])
+			}
+			buf.WriteString("\\ufffd")
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	if start < len(s) {
+		buf.WriteString(s[start:])
+	}
+}
+`
@@ -1,387 +1,635 @@
+// Command dynamodb-marshal generates the methods of the
+// dynamodb.Item, KeyEncoder, ExpectedEncoder and ItemDecoder
+// interfaces for structs, avoiding the cost of reflection.
+//
+// It can be run on individual files, generating code for every
+// struct declared in them:
+//
+//     $ dynamodb-marshal model.go
+//
+// Or on a package directory, generating code for the types
+// selected with -type or marked with a //dynamodb:generate
+// comment:
+//
+//     //go:generate dynamodb-marshal
+//
+//     //dynamodb:generate
+//     type Contribution struct {
+//         ...
+//     }
+//
+// When run by go generate without arguments it processes the
+// package in the current directory. The code for the types
+// declared in a file foo.go is written to foo_marshal.go, in the
+// order they are declared, and the helpers it shares with the
+// rest of the package to dynamodb_marshal_helpers.go.
+//
+// Besides the types supported by the reflection-based encoder,
+// named types are encoded like their underlying types, nested
+// structs and maps with string keys as map attributes, other
+// slices as list attributes and nil pointers, maps and lists as
+// null. Nested structs declared in the package get generated
+// methods too, unless they have hand-written ones.
+//
+// With -repo, a typed wrapper of dynamodb.Table is generated
+// for each type with a HASH key, e.g. for a User keyed by a
+// string ID and a time.Time:
+//
+//     users := NewUserTable(client.Table("users"))
+//     user, err := users.Get(ctx, id, created, false)
+//     history, err := users.Query(ctx, id)
+//
+// Errors are reported with the position of the offending
+// field and result in a non-zero exit status. With -check,
+// nothing is written and the exit status is non-zero if any
+// generated file is out of date, which is useful in CI.
 package main
 
 import (
 	"bytes"
+	"errors"
 	"flag"
 	"fmt"
 	"go/ast"
+	"go/format"
+	"go/importer"
 	"go/parser"
 	"go/token"
+	"go/types"
+	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 	"unicode"
 	"unicode/utf8"
 )
 
-type fieldInfo struct {
-	dbName string
-	kind   string
-	name   string
+// marker selects a type for generation in package mode.
+const marker = "//dynamodb:generate"
+
+// parseTag parses the ddb key of a struct tag the same way the
+// reflection-based encoder in the dynamodb package does, i.e.
+// `ddb:"name,HASH"`, `ddb:",RANGE"` or `ddb:"-"`. Other options,
+// e.g. gsi=ByEmail:HASH, don't affect the generated code.
+func parseTag(tag string) (name, keyType string, skip bool) {
+	value := reflect.StructTag(tag).Get("ddb")
+	if value == "" {
+		return
+	}
+	split := strings.Split(value, ",")
+	for _, option := range split[1:] {
+		if option == "HASH" || option == "RANGE" {
+			keyType = option
+		}
+	}
+	name = split[0]
+	if name == "-" {
+		return "", "", true
+	}
+	return name, keyType, false
 }
 
-type model struct {
-	fields []fieldInfo
-	name   string
+// hasOption reports whether the ddb key of a struct tag has
+// option, e.g. ttl.
+func hasOption(tag, option string) bool {
+	split := strings.Split(reflect.StructTag(tag).Get("ddb"), ",")
+	for _, o := range split[1:] {
+		if o == option {
+			return true
+		}
+	}
+	return false
 }
 
-var header = []byte(`// DO NOT EDIT.
-// Auto-generated template file by dynamodb-marshal.
-
-package `)
-
-var kindMap = map[string]string{
-	"[]byte":   "B",
-	"bool":     "N",
-	"int":      "N",
-	"int64":    "N",
-	"string":   "S",
-	"time":     "N",
-	"uint":     "N",
-	"uint64":   "N",
-	"[][]byte": "BS",
-	"[]bool":   "NS",
-	"[]int":    "NS",
-	"[]int64":  "NS",
-	"[]string": "SS",
+// pkg is a parsed and type-checked package.
+type pkg struct {
+	dir       string
+	name      string
+	fset      *token.FileSet
+	files     map[string]*ast.File
+	generated map[string]bool
+	types     *types.Package
+
+	// nested are the struct types of the package used by the
+	// fields of the models collected so far.
+	nested []*types.TypeName
 }
 
-func parseFile(path string, force bool) {
-	dir, filename := filepath.Split(path)
-	if !strings.HasSuffix(filename, ".go") {
-		log.Printf("%s does not look like a go file", filename)
-	}
+// legacyHeader starts files written by older versions of
+// dynamodb-marshal.
+const legacyHeader = `// DO NOT EDIT.
+// Auto-generated template file by dynamodb-marshal.`
 
-	log.Printf("Parsing %s\n", path)
-	fset := token.NewFileSet()
-	pkg, err := parser.ParseFile(fset, path, nil, 0)
+// generated returns whether a source file was written by
+// dynamodb-marshal.
+func generated(src []byte) bool {
+	return bytes.HasPrefix(src, []byte(header)) || bytes.HasPrefix(src, []byte(legacyHeader))
+}
+
+// fset and imports are shared by all the packages loaded so
+// that their imports are only type-checked once.
+var (
+	fset    = token.NewFileSet()
+	imports = importer.ForCompiler(fset, "source", nil)
+)
+
+// loadPackage parses and type-checks the package in dir,
+// ignoring test files.
+func loadPackage(dir string) (*pkg, error) {
+	filter := func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}
+	pkgs, err := parser.ParseDir(fset, dir, filter, parser.ParseComments)
 	if err != nil {
-		log.Print(err)
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("%s: expected exactly one package, found %d", dir, len(pkgs))
+	}
+	p := &pkg{dir: dir, fset: fset, generated: map[string]bool{}}
+	for name, astPkg := range pkgs {
+		p.name = name
+		p.files = astPkg.Files
+	}
+	for path := range p.files {
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		p.generated[path] = generated(src)
 	}
 
-	newpath := filepath.Join(dir, fmt.Sprintf("%s_marshal.go", filename[:len(filename)-3]))
-	_, err = os.Stat(newpath)
-	if err == nil && !force {
-		log.Printf("%s already exists! please specify --force to overwrite", newpath)
+	// Type errors are tolerated since the package may not
+	// compile until its code has been generated.
+	var files []*ast.File
+	for _, file := range p.files {
+		files = append(files, file)
+	}
+	conf := types.Config{
+		Importer: imports,
+		Error:    func(error) {},
 	}
+	p.types, _ = conf.Check(p.name, fset, files, nil)
+	return p, nil
+}
 
-	prev := ""
-	models := []*model{}
+// marked returns whether a type declaration carries the
+// //dynamodb:generate marker.
+func marked(groups ...*ast.CommentGroup) bool {
+	for _, group := range groups {
+		if group == nil {
+			continue
+		}
+		for _, comment := range group.List {
+			if strings.TrimSpace(comment.Text) == marker {
+				return true
+			}
+		}
+	}
+	return false
+}
 
-	ast.Inspect(pkg, func(n ast.Node) bool {
-		if s, ok := n.(*ast.StructType); ok {
-			fields := []fieldInfo{}
-			for _, field := range s.Fields.List {
-				if field.Names == nil {
+// models finds the structs to generate code for. If names is
+// not empty, only the named types are selected. Otherwise,
+// every struct declared in files is selected or, if files is
+// nil, every struct marked with //dynamodb:generate. The
+// structs nested in those selected are added to them.
+func (p *pkg) models(files map[string]bool, names map[string]bool) ([]*model, error) {
+	paths := make([]string, 0, len(p.files))
+	for path := range p.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	found := map[string]bool{}
+	var models []*model
+	var errs errorList
+	for _, path := range paths {
+		if files != nil && !files[path] || p.generated[path] {
+			continue
+		}
+		for _, decl := range p.files[path].Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				spec := spec.(*ast.TypeSpec)
+				if _, ok := spec.Type.(*ast.StructType); !ok {
 					continue
 				}
-				name := field.Names[0].Name
-				dbName := ""
-				kind := ""
-				if field.Tag != nil {
-					tag := field.Tag.Value[1 : len(field.Tag.Value)-1]
-					if tag == "-" {
+				name := spec.Name.Name
+				switch {
+				case len(names) > 0:
+					if !names[name] {
 						continue
 					}
-					dbName = tag
-				}
-				if dbName == "" {
-					dbName = name
-					rune, _ := utf8.DecodeRuneInString(name)
-					if !unicode.IsUpper(rune) {
+				case files == nil:
+					if !marked(gen.Doc, spec.Doc) {
 						continue
 					}
 				}
-				switch expr := field.Type.(type) {
-				case *ast.Ident:
-					switch expr.Name {
-					case "bool", "string", "int", "int64", "uint", "uint64":
-						kind = expr.Name
-					}
-				case *ast.ArrayType:
-					if expr.Len == nil { // slice type
-						switch iexpr := expr.Elt.(type) {
-						case *ast.Ident:
-							switch iexpr.Name {
-							case "byte", "bool", "string", "int", "int64", "uint", "uint64":
-								kind = "[]" + iexpr.Name
-							}
-						case *ast.ArrayType:
-							if iexpr.Len == nil {
-								if iiexpr, ok := iexpr.Elt.(*ast.Ident); ok {
-									if iiexpr.Name == "byte" {
-										kind = "[][]byte"
-									}
-								}
-							}
-						}
-					}
-				case *ast.SelectorExpr:
-					if lexpr, ok := expr.X.(*ast.Ident); ok {
-						if lexpr.Name == "time" && expr.Sel.Name == "Time" {
-							kind = "time"
-						}
-					}
-				}
-				if kind == "" {
-					log.Print("unsupported: %v field (%s.%s)", field.Type, prev, name)
-					continue
-				}
-				fields = append(fields, fieldInfo{
-					dbName: dbName,
-					kind:   kind,
-					name:   name,
-				})
+				found[name] = true
+				m, err := p.model(name, path)
+				errs.add(err)
+				models = append(models, m)
 			}
-			model := &model{
-				fields: fields,
-				name:   prev,
-			}
-			models = append(models, model)
-		}
-		switch x := n.(type) {
-		case *ast.Ident:
-			prev = x.Name
-		}
-		return true
-	})
-
-	buf := &bytes.Buffer{}
-	buf.Write(header)
-	buf.Write([]byte(pkg.Name.Name))
-	buf.Write([]byte("\n\nimport (\n\t\"bytes\"\n\t\"encoding/base64\"\n\t\"strconv\"\n\t\"time\"\n\t\"unicode/utf8\"\n)\n\n"))
-
-	for _, model := range models {
-		ref := strings.ToLower(string(model.name[0]))
-		fmt.Fprintf(buf, "func (%s *%s) Encode(buf *bytes.Buffer) {\n", ref, model.name)
-		last := len(model.fields) - 1
-		close := `{"`
-		written := false
-		for idx, field := range model.fields {
-			dbKind, ok := kindMap[field.kind]
-			if !ok {
-				log.Print("unsupported kind: %s", field.kind)
+		}
+	}
+
+	// Nested structs are generated along with the models using
+	// them, unless they have hand-written methods.
+	for len(p.nested) > 0 {
+		obj := p.nested[0]
+		p.nested = p.nested[1:]
+		if found[obj.Name()] || p.handWritten(obj) {
+			continue
+		}
+		found[obj.Name()] = true
+		m, err := p.model(obj.Name(), p.fset.Position(obj.Pos()).Filename)
+		errs.add(err)
+		models = append(models, m)
+	}
+	for name := range names {
+		if !found[name] {
+			errs.add(fmt.Errorf("%s: struct type %s not found", relative(p.dir), name))
+		}
+	}
+	return models, errs.err()
+}
+
+// position formats pos as file:line:column.
+func (p *pkg) position(pos token.Pos) string {
+	position := p.fset.Position(pos)
+	position.Filename = relative(position.Filename)
+	return position.String()
+}
+
+// model collects the fields of the struct type name.
+func (p *pkg) model(name, path string) (*model, error) {
+	obj := p.types.Scope().Lookup(name)
+	m := &model{name: name, file: path, pos: obj.Pos()}
+	st, ok := obj.Type().Underlying().(*types.Struct)
+	if !ok {
+		return m, fmt.Errorf("%s: %s is not a struct type", p.position(obj.Pos()), name)
+	}
+	var errs errorList
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		if field.Anonymous() {
+			continue
+		}
+		dbName, keyType, skip := parseTag(st.Tag(i))
+		if skip {
+			continue
+		}
+		if dbName == "" {
+			dbName = field.Name()
+			rune, _ := utf8.DecodeRuneInString(dbName)
+			if !unicode.IsUpper(rune) {
 				continue
 			}
-			prefix := `"`
-			suffix := `"`
-			if len(dbKind) == 2 {
-				prefix = "["
-				suffix = "]"
-			}
-			open := fmt.Sprintf(`%s%s":{"%s":%s`, close, field.dbName, dbKind, prefix)
-			comma := ","
-			if idx == last {
-				comma = ""
-			}
-			fmt.Fprintf(buf, "\tbuf.WriteString(`%s`)\n", open)
-			close = fmt.Sprintf(`%s}%s"`, suffix, comma)
-			written = true
-			selector := fmt.Sprintf("%s.%s", ref, field.name)
-			if len(dbKind) == 2 {
-				fmt.Fprintf(buf, "\tfor idx, elem := range %s {\n", selector)
-				fmt.Fprint(buf, "\t\tbuf.WriteByte('\"')\n")
-				write(buf, "\t\t", field.kind[2:], "elem")
-				fmt.Fprintf(buf, "\t\tif idx == len(%s)-1 {\n", selector)
-				fmt.Fprint(buf, "\t\t\tbuf.WriteByte('\"')\n")
-				fmt.Fprint(buf, "\t\t} else {\n")
-				fmt.Fprint(buf, "\t\t\tbuf.WriteString(`\",`)\n")
-				fmt.Fprint(buf, "\t\t}\n")
-				fmt.Fprint(buf, "\t}\n")
-			} else {
-				write(buf, "\t", field.kind, selector)
-			}
 		}
-		if written {
-			fmt.Fprintf(buf, "\tbuf.WriteString(`%s}`)\n", close[:len(close)-1])
+		if field.Type() == types.Typ[types.Invalid] {
+			errs.add(fmt.Errorf("%s: cannot resolve the type of field %s.%s", p.position(field.Pos()), name, field.Name()))
+			continue
 		}
-		fmt.Fprintf(buf, "}\n\n")
-		fmt.Fprintf(buf, "func (%s *%s) Decode(data map[string]map[string]interface{}) {\n", ref, model.name)
-		close = ""
-		for _, field := range model.fields {
-			dbKind, ok := kindMap[field.kind]
-			if !ok {
+		typ, err := p.typeOf(field.Type())
+		if err != nil {
+			errs.add(fmt.Errorf("%s: %v for field %s.%s", p.position(field.Pos()), err, name, field.Name()))
+			continue
+		}
+		if hasOption(st.Tag(i), "ttl") {
+			if typ, err = ttlType(typ); err != nil {
+				errs.add(fmt.Errorf("%s: %v for field %s.%s", p.position(field.Pos()), err, name, field.Name()))
 				continue
 			}
-			selector := fmt.Sprintf("%s.%s", ref, field.name)
-			if len(dbKind) == 2 {
-				fmt.Fprintf(buf, "%s\tif vals, ok := data[\"%s\"][\"%s\"].([]interface{}); ok {\n", close, field.dbName, dbKind)
-				fmt.Fprint(buf, "\t\tfor _, sval := range vals {\n")
-				fmt.Fprint(buf, "\t\t\tval := sval.(string)\n")
-				readMulti(buf, "\t\t\t", field.kind, selector)
-				fmt.Fprint(buf, "\t\t}\n")
-			} else {
-				fmt.Fprintf(buf, "%s\tif val, ok := data[\"%s\"][\"%s\"].(string); ok {\n", close, field.dbName, dbKind)
-				read(buf, "\t\t", field.kind, selector)
+		}
+		if keyType != "" && len(kindMap[typ.kind]) != 1 {
+			errs.add(fmt.Errorf("%s: key field %s.%s must be a string, number or binary", p.position(field.Pos()), name, field.Name()))
+			continue
+		}
+		m.fields = append(m.fields, fieldInfo{
+			dbName:  dbName,
+			keyType: keyType,
+			name:    field.Name(),
+			typ:     typ,
+		})
+	}
+	return m, errs.err()
+}
+
+// ttlType describes the encoding of a field of type t tagged
+// with ttl, as epoch seconds.
+func ttlType(t *typeInfo) (*typeInfo, error) {
+	ttl := *t
+	ttl.named = false
+	switch {
+	case t.kind == "time":
+		ttl.kind = "ttl"
+	case t.typ.String() == "time.Duration":
+		ttl.kind = "ttlDuration"
+	default:
+		return nil, errors.New("ttl needs a time.Time or time.Duration")
+	}
+	return &ttl, nil
+}
+
+// typeOf describes how values of type t are encoded. Named
+// types are encoded like their underlying types, except for
+// structs which are encoded as map attributes by their own
+// Encode and Decode methods.
+func (p *pkg) typeOf(t types.Type) (*typeInfo, error) {
+	unsupported := fmt.Errorf("unsupported type %s", t)
+	switch u := t.(type) {
+	case *types.Basic:
+		switch u.Kind() {
+		case types.Bool, types.String, types.Int, types.Int64, types.Uint, types.Uint64:
+			return &typeInfo{kind: u.Name(), typ: t}, nil
+		}
+	case *types.Pointer:
+		elem, err := p.typeOf(u.Elem())
+		if err != nil {
+			return nil, err
+		}
+		if elem.kind == "ptr" {
+			return nil, unsupported
+		}
+		return &typeInfo{kind: "ptr", typ: t, elem: elem}, nil
+	case *types.Slice:
+		if types.Identical(u.Elem(), types.Typ[types.Uint8]) {
+			return &typeInfo{kind: "[]byte", typ: t}, nil
+		}
+		elem, err := p.typeOf(u.Elem())
+		if err != nil {
+			return nil, err
+		}
+		switch elem.kind {
+		case "[]byte", "bool", "string", "int", "int64", "uint", "uint64":
+			return &typeInfo{kind: "[]" + elem.kind, typ: t, elem: elem}, nil
+		}
+		return &typeInfo{kind: "list", typ: t, elem: elem}, nil
+	case *types.Map:
+		key, err := p.typeOf(u.Key())
+		if err != nil || key.kind != "string" {
+			return nil, unsupported
+		}
+		elem, err := p.typeOf(u.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &typeInfo{kind: "map", typ: t, key: key, elem: elem}, nil
+	case *types.Named:
+		obj := u.Obj()
+		if obj.Pkg() != nil && obj.Pkg().Path() == "time" && obj.Name() == "Time" {
+			return &typeInfo{kind: "time", typ: t}, nil
+		}
+		if _, ok := u.Underlying().(*types.Struct); ok {
+			return p.structType(u)
+		}
+		under, err := p.typeOf(u.Underlying())
+		if err != nil {
+			return nil, unsupported
+		}
+		named := *under
+		named.typ = t
+		named.named = true
+		return &named, nil
+	}
+	return nil, unsupported
+}
+
+// structType describes a nested struct. Those declared in the
+// package are generated too, while those from other packages
+// must already have the methods.
+func (p *pkg) structType(t *types.Named) (*typeInfo, error) {
+	obj := t.Obj()
+	if obj.Pkg() == p.types {
+		p.nested = append(p.nested, obj)
+		return &typeInfo{kind: "struct", typ: t}, nil
+	}
+	for _, method := range []string{"Encode", "Decode"} {
+		if m, _, _ := types.LookupFieldOrMethod(types.NewPointer(t), false, obj.Pkg(), method); m == nil {
+			return nil, fmt.Errorf("type %s has no %s method", t, method)
+		}
+	}
+	return &typeInfo{kind: "struct", typ: t}, nil
+}
+
+// handWritten returns whether the struct type obj has a Decode
+// method declared outside of the generated files.
+func (p *pkg) handWritten(obj *types.TypeName) bool {
+	m, _, _ := types.LookupFieldOrMethod(types.NewPointer(obj.Type()), false, obj.Pkg(), "Decode")
+	if m == nil {
+		return false
+	}
+	return !p.generated[p.fset.Position(m.Pos()).Filename]
+}
+
+// generate writes the code for models, grouped by the file they
+// are declared in and in declaration order, along with the
+// shared helpers file.
+func (p *pkg) generate(models []*model, opts *options) error {
+	sort.Sort(byPosition(models))
+	byFile := map[string][]*model{}
+	var paths []string
+	for _, m := range models {
+		if _, ok := byFile[m.file]; !ok {
+			paths = append(paths, m.file)
+		}
+		byFile[m.file] = append(byFile[m.file], m)
+	}
+	var errs errorList
+	for _, path := range paths {
+		g := newGenerator(p.types)
+		for _, m := range byFile[path] {
+			g.model(m)
+			if opts.repo {
+				g.repository(m)
 			}
-			close = "\t}\n"
 		}
-		fmt.Fprintf(buf, "%s}\n\n", close)
+		out := strings.TrimSuffix(path, ".go") + "_marshal.go"
+		errs.add(output(out, g.file(p.name), opts))
 	}
+	g := newGenerator(p.types)
+	g.helpers()
+	errs.add(output(filepath.Join(p.dir, helpersFile), g.file(p.name), opts))
+	return errs.err()
+}
 
-	buf.Write(jsonSupport)
+// output formats a generated file and writes it, or in check
+// mode verifies that it is up to date. It refuses to overwrite
+// a file which wasn't generated unless opts.force is set.
+func output(path string, src []byte, opts *options) error {
+	formatted, err := format.Source(src)
+	if err != nil {
+		return fmt.Errorf("%s: generated invalid code: %v", relative(path), err)
+	}
+	existing, err := ioutil.ReadFile(path)
+	if opts.check {
+		if err != nil || !bytes.Equal(existing, formatted) {
+			return fmt.Errorf("%s: out of date, run dynamodb-marshal", relative(path))
+		}
+		return nil
+	}
+	if err == nil {
+		if bytes.Equal(existing, formatted) {
+			return nil
+		}
+		if !generated(existing) && !opts.force {
+			return fmt.Errorf("%s: already exists and wasn't generated, use -force to overwrite", relative(path))
+		}
+	}
+	log.Printf("writing %s", relative(path))
+	return ioutil.WriteFile(path, formatted, 0644)
+}
 
-	log.Printf("Writing %s\n", newpath)
-	newfile, err := os.Create(newpath)
+// relative shortens path relative to the working directory
+// when possible.
+func relative(path string) string {
+	wd, err := os.Getwd()
 	if err != nil {
-		log.Print(err)
+		return path
+	}
+	rel, err := filepath.Rel(wd, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
 	}
+	return rel
+}
 
-	newfile.Write(buf.Bytes())
-	newfile.Close()
+// errorList collects errors so that all of them are reported
+// rather than just the first.
+type errorList []error
 
+func (l *errorList) add(err error) {
+	if list, ok := err.(errorList); ok {
+		*l = append(*l, list...)
+	} else if err != nil {
+		*l = append(*l, err)
+	}
 }
 
-func read(buf *bytes.Buffer, lead, kind, selector string) {
-	switch kind {
-	case "[]byte":
-		fmt.Fprintf(buf, "%s%s, _ = base64.StdEncoding.DecodeString(val)\n", lead, selector)
-	case "bool":
-		fmt.Fprintf(buf, "%sif val == \"1\" {\n", lead)
-		fmt.Fprintf(buf, "%s\t%s = true\n", lead, selector)
-		fmt.Fprintf(buf, "%s} else if val == \"0\" {\n", lead)
-		fmt.Fprintf(buf, "%s\t%s = false\n", lead, selector)
-		fmt.Fprintf(buf, "%s}\n", lead)
-	case "string":
-		fmt.Fprintf(buf, "%s%s = val\n", lead, selector)
-	case "int":
-		fmt.Fprintf(buf, "%stmp, _ := strconv.ParseInt(val, 10, 64)\n", lead)
-		fmt.Fprintf(buf, "%s%s = int(tmp)\n", lead, selector)
-	case "int64":
-		fmt.Fprintf(buf, "%s%s, _ = strconv.ParseInt(val, 10, 64)\n", lead, selector)
-	case "uint":
-		fmt.Fprintf(buf, "%stmp, _ := strconv.ParseUint(val, 10, 64)\n", lead)
-		fmt.Fprintf(buf, "%s%s = uint(tmp)\n", lead, selector)
-	case "uint64":
-		fmt.Fprintf(buf, "%s%s, _ = strconv.ParseUint(val, 10, 64)\n", lead, selector)
-	case "time":
-		fmt.Fprintf(buf, "%stmp, _ := strconv.ParseInt(val, 10, 64)\n", lead)
-		fmt.Fprintf(buf, "%s%s = time.Unix(0, tmp).UTC()\n", lead, selector)
+func (l errorList) err() error {
+	if len(l) == 0 {
+		return nil
 	}
+	return l
 }
 
-func readMulti(buf *bytes.Buffer, lead, kind, selector string) {
-	switch kind {
-	case "[][]byte":
-		fmt.Fprintf(buf, "%stmp, _ := base64.StdEncoding.DecodeString(val)\n", lead)
-		fmt.Fprintf(buf, "%s%s = append(%s, tmp)\n", lead, selector, selector)
-	case "bool":
-		fmt.Fprintf(buf, "%sif val == \"1\" {\n", lead)
-		fmt.Fprintf(buf, "%s\t%s = append(%s, true)\n", lead, selector, selector)
-		fmt.Fprintf(buf, "%s} else if val == \"0\" {\n", lead)
-		fmt.Fprintf(buf, "%s\t%s = append(%s, false)\n", lead, selector, selector)
-		fmt.Fprintf(buf, "%s}\n", lead)
-	case "[]string":
-		fmt.Fprintf(buf, "%s%s = append(%s, val)\n", lead, selector, selector)
-	case "[]int":
-		fmt.Fprintf(buf, "%stmp, _ := strconv.ParseInt(val, 10, 64)\n", lead)
-		fmt.Fprintf(buf, "%s%s = append(%s, int(tmp))\n", lead, selector, selector)
-	case "[]int64":
-		fmt.Fprintf(buf, "%stmp, _ := strconv.ParseInt(val, 10, 64)\n", lead, selector)
-		fmt.Fprintf(buf, "%s%s = append(%s, tmp)\n", lead, selector, selector)
-	case "[]uint":
-		fmt.Fprintf(buf, "%stmp, _ := strconv.ParseUint(val, 10, 64)\n", lead, selector)
-		fmt.Fprintf(buf, "%s%s = append(%s, uint(tmp))\n", lead, selector, selector)
-	case "[]uint64":
-		fmt.Fprintf(buf, "%stmp, _ := strconv.ParseUint(val, 10, 64)\n", lead, selector)
-		fmt.Fprintf(buf, "%s%s = append(%s, tmp)\n", lead, selector, selector)
+func (l errorList) Error() string {
+	msgs := make([]string, len(l))
+	for i, err := range l {
+		msgs[i] = err.Error()
 	}
+	return strings.Join(msgs, "\n")
 }
 
-func write(buf *bytes.Buffer, lead, kind, selector string) {
-	switch kind {
-	case "[]byte":
-		fmt.Fprintf(buf, "%sbuf.WriteString(base64.StdEncoding.EncodeToString(%s))\n", lead, selector)
-	case "bool":
-		fmt.Fprintf(buf, "%sif %s {\n", lead, selector)
-		fmt.Fprintf(buf, "%s\tbuf.WriteByte('1')\n", lead)
-		fmt.Fprintf(buf, "%s} else {\n", lead)
-		fmt.Fprintf(buf, "%s\tbuf.WriteByte('0')\n", lead)
-		fmt.Fprintf(buf, "%s}\n", lead)
-	case "string":
-		fmt.Fprintf(buf, "%stoJSON(%s, buf)\n", lead, selector)
-	case "int":
-		fmt.Fprintf(buf, "%sbuf.WriteString(strconv.FormatInt(int64(%s), 10))\n", lead, selector)
-	case "int64":
-		fmt.Fprintf(buf, "%sbuf.WriteString(strconv.FormatInt(%s, 10))\n", lead, selector)
-	case "uint":
-		fmt.Fprintf(buf, "%sbuf.WriteString(strconv.FormatUint(int64(%s), 10))\n", lead, selector)
-	case "uint64":
-		fmt.Fprintf(buf, "%sbuf.WriteString(strconv.FormatUint(%s, 10))\n", lead, selector)
-	case "time":
-		fmt.Fprintf(buf, "%sbuf.WriteString(strconv.FormatInt(%s.UnixNano(), 10))\n", lead, selector)
-	}
+type options struct {
+	names map[string]bool
+	force bool
+	check bool
+	repo  bool
 }
 
-var jsonSupport = []byte(`
-// Adapted from the encoding/json package in the standard
-// library.
-const hex = "0123456789abcdef"
-
-func toJSON(s string, buf *bytes.Buffer) {
-	start := 0
-	for i := 0; i < len(s); {
-		if b := s[i]; b < utf8.RuneSelf {
-			if 0x20 <= b && b != '\\' && b != '"' && b != '<' && b != '>' && b != '&' {
-				i++
-				continue
-			}
-			if start < i {
-				buf.WriteString(s[start:i])
+// run generates code for the files or package directories in
+// args.
+func run(args []string, opts *options) error {
+	// files are grouped by directory so that each package is
+	// only loaded once
+	dirs := map[string]map[string]bool{}
+	var order []string
+	for _, arg := range args {
+		path, err := filepath.Abs(arg)
+		if err != nil {
+			return err
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		dir, files := path, map[string]bool(nil)
+		if !info.IsDir() {
+			if !strings.HasSuffix(path, ".go") {
+				return fmt.Errorf("%s: does not look like a go file", arg)
 			}
-			switch b {
-			case '\\', '"':
-				buf.WriteByte('\\')
-				buf.WriteByte(b)
-			case '\n':
-				buf.WriteByte('\\')
-				buf.WriteByte('n')
-			case '\r':
-				buf.WriteByte('\\')
-				buf.WriteByte('r')
-			default:
-				buf.WriteString("\\u00")
-				buf.WriteByte(hex[b>>4])
-				buf.WriteByte(hex[b&0xF])
+			dir = filepath.Dir(path)
+			files = dirs[dir]
+			if files == nil {
+				files = map[string]bool{}
 			}
-			i++
-			start = i
+			files[path] = true
+		}
+		if _, ok := dirs[dir]; !ok {
+			order = append(order, dir)
+		}
+		dirs[dir] = files
+	}
+
+	var errs errorList
+	for _, dir := range order {
+		p, err := loadPackage(dir)
+		if err != nil {
+			errs.add(err)
 			continue
 		}
-		c, size := utf8.DecodeRuneInString(s[i:])
-		if c == utf8.RuneError && size == 1 {
-			if start < i {
-				buf.WriteString(s[start:i])
-			}
-			buf.WriteString("\\ufffd")
-			i += size
-			start = i
+		models, err := p.models(dirs[dir], opts.names)
+		if err != nil {
+			errs.add(err)
 			continue
 		}
-		i += size
-	}
-	if start < len(s) {
-		buf.WriteString(s[start:])
+		if len(models) == 0 {
+			errs.add(errors.New(relative(dir) + ": no types selected, use -type or mark them with " + marker))
+			continue
+		}
+		errs.add(p.generate(models, opts))
 	}
+	return errs.err()
 }
-`)
 
-var flagForce = flag.Bool("force", false, "overwrite existing marshal files")
+var (
+	flagCheck = flag.Bool("check", false, "only check that generated files are up to date")
+	flagForce = flag.Bool("force", false, "overwrite existing files which weren't generated")
+	flagRepo  = flag.Bool("repo", false, "generate a typed Table wrapper for each type with a HASH key")
+	flagType  = flag.String("type", "", "comma-separated list of types to generate code for")
+)
 
 func main() {
+	log.SetFlags(0)
+	log.SetPrefix("dynamodb-marshal: ")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: dynamodb-marshal [flags] [file.go ... | dir]")
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
-	if len(flag.Args()) < 1 {
-		fmt.Println("usage: dynamodb-marshal file1.go [file2.go ...]")
-		os.Exit(1)
+	args := flag.Args()
+	if len(args) == 0 {
+		if os.Getenv("GOFILE") == "" {
+			flag.Usage()
+			os.Exit(2)
+		}
+		// run by go generate
+		args = []string{"."}
 	}
 
-	for _, file := range flag.Args() {
-		path, err := filepath.Abs(file)
-		if err != nil {
-			log.Print(err)
+	opts := &options{
+		names: map[string]bool{},
+		force: *flagForce,
+		check: *flagCheck,
+		repo:  *flagRepo,
+	}
+	if *flagType != "" {
+		for _, name := range strings.Split(*flagType, ",") {
+			opts.names[strings.TrimSpace(name)] = true
 		}
-		parseFile(path, *flagForce)
+	}
+
+	if err := run(args, opts); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 }
@@ -3,10 +3,19 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/groupme/dynamodb-1"
+	"golang.org/x/net/context"
 )
 
 var testModel = &Model{
@@ -81,3 +90,447 @@ func BenchmarkCustomMarshalJSON(b *testing.B) {
 		}
 	}
 }
+
+func BenchmarkDecode(b *testing.B) {
+	var buf bytes.Buffer
+	testModel.Encode(&buf)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var data map[string]map[string]interface{}
+		json.Unmarshal(buf.Bytes(), &data)
+		(&Model{}).Decode(data)
+	}
+}
+
+func BenchmarkDecodeItem(b *testing.B) {
+	var buf bytes.Buffer
+	testModel.Encode(&buf)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := dynamodb.Unmarshal(buf.Bytes(), &Model{}); err != nil {
+			b.Error(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalReflect(b *testing.B) {
+	var buf bytes.Buffer
+	testModel.Encode(&buf)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := dynamodb.Unmarshal(buf.Bytes(), &ModelWithoutEncode{}); err != nil {
+			b.Error(err)
+		}
+	}
+}
+
+// reflectModel and reflectTaggedModel share the fields and
+// tags of the generated types but not their methods, so the
+// dynamodb package encodes them by reflection.
+type reflectModel Model
+type reflectTaggedModel TaggedModel
+
+var testTaggedModel = &TaggedModel{
+	ID:          "id-1",
+	Created:     time.Now(),
+	Secret:      "secret",
+	Count:       3,
+	Total:       1 << 40,
+	Int64:       -64,
+	Uints:       []uint{1, 2},
+	Uint64s:     []uint64{1 << 40},
+	Int64s:      []int64{-1, 1},
+	Description: "quotes \" and <tags> & newlines\n",
+}
+
+func TestEncodeParity(t *testing.T) {
+	cases := []struct {
+		generated dynamodb.Item
+		reflected interface{}
+	}{
+		{testModel, (*reflectModel)(testModel)},
+		{testTaggedModel, (*reflectTaggedModel)(testTaggedModel)},
+	}
+	for _, c := range cases {
+		var buf bytes.Buffer
+		c.generated.Encode(&buf)
+		want, _ := dynamodb.Marshal(c.reflected)
+		if buf.String() != string(want) {
+			t.Errorf("%T", c.generated)
+			t.Error("want", string(want))
+			t.Error("got ", buf.String())
+		}
+	}
+}
+
+func TestDecodeParity(t *testing.T) {
+	cases := []struct {
+		generated dynamodb.Item
+		reflected interface{}
+	}{
+		{testModel, &reflectModel{}},
+		{testTaggedModel, &reflectTaggedModel{}},
+	}
+	for _, c := range cases {
+		var buf bytes.Buffer
+		c.generated.Encode(&buf)
+		generated := reflect.New(reflect.TypeOf(c.generated).Elem()).Interface()
+		dynamodb.Unmarshal(buf.Bytes(), generated)
+		dynamodb.Unmarshal(buf.Bytes(), c.reflected)
+		want := reflect.ValueOf(c.reflected).Convert(reflect.TypeOf(generated)).Interface()
+		if !reflect.DeepEqual(generated, want) {
+			t.Errorf("%T", c.generated)
+			t.Error("want", want)
+			t.Error("got ", generated)
+		}
+	}
+}
+
+func TestNestedRoundTrip(t *testing.T) {
+	note := "note"
+	created := time.Unix(0, time.Now().UnixNano()).UTC()
+	in := &NestedModel{
+		ID:       "id-1",
+		Status:   "active",
+		Statuses: []Status{"a", "b"},
+		Home:     Address{Street: "Main \"St\"", Zip: 10001},
+		Previous: []Address{{Street: "First"}, {Street: "Second", Zip: 2}},
+		Labels:   map[string]string{"a": "1", "b": "2"},
+		Scores:   map[Status][]int{"x": []int{1, 2}},
+		Parent:   &NestedModel{ID: "parent", Work: &Address{Zip: 1}},
+		Note:     &note,
+		Created:  []time.Time{created},
+	}
+	var buf bytes.Buffer
+	in.Encode(&buf)
+	var data map[string]map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &data); err != nil {
+		t.Fatal(err, buf.String())
+	}
+	out := &NestedModel{}
+	out.Decode(data)
+	if !reflect.DeepEqual(in, out) {
+		t.Error("want", in)
+		t.Error("got ", out)
+	}
+	streamed := &NestedModel{}
+	if err := dynamodb.Unmarshal(buf.Bytes(), streamed); err != nil || !reflect.DeepEqual(in, streamed) {
+		t.Error("want", in)
+		t.Error("got ", streamed, err)
+	}
+
+	buf.Reset()
+	in.EncodeExpected(&buf)
+	if !json.Valid(buf.Bytes()) {
+		t.Error("invalid expected:", buf.String())
+	}
+}
+
+func TestNestedTypes(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.go": "package models\n\ntype Name string\n\n//dynamodb:generate\ntype A struct {\n\tName  Name\n\tB     *B\n\tC     []C\n}\n",
+		"b.go": "package models\n\ntype B struct {\n\tCount int\n}\n",
+		"c.go": "package models\n\nimport \"bytes\"\n\ntype C struct{}\n\nfunc (c *C) Encode(buf *bytes.Buffer) {}\n\nfunc (c *C) Decode(data map[string]map[string]interface{}) {}\n",
+	})
+	defer os.RemoveAll(dir)
+
+	if err := run([]string{dir}, &options{}); err != nil {
+		t.Fatal(err)
+	}
+	a, _ := ioutil.ReadFile(filepath.Join(dir, "a_marshal.go"))
+	b, _ := ioutil.ReadFile(filepath.Join(dir, "b_marshal.go"))
+	if !strings.Contains(string(a), "toJSON(string(a.Name), buf)") || !strings.Contains(string(a), "a.B.Encode(buf)") {
+		t.Error("a_marshal.go:", string(a))
+	}
+	if !strings.Contains(string(b), "func (b *B) Encode(") {
+		t.Error("b_marshal.go:", string(b))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "c_marshal.go")); err == nil {
+		t.Error("generated code for a type with hand-written methods")
+	}
+
+	// the generated methods are regenerated rather than reused
+	if err := run([]string{dir}, &options{check: true}); err != nil {
+		t.Error(err)
+	}
+}
+
+type reflectSessionModel SessionModel
+
+func TestTTL(t *testing.T) {
+	in := &SessionModel{Token: "t", Expires: time.Unix(1700000000, 0)}
+	var buf bytes.Buffer
+	in.Encode(&buf)
+	want, _ := dynamodb.Marshal((*reflectSessionModel)(in))
+	if buf.String() != string(want) {
+		t.Error("want", string(want))
+		t.Error("got ", buf.String())
+	}
+
+	in.Renew = time.Hour
+	buf.Reset()
+	in.Encode(&buf)
+	out := &SessionModel{}
+	if err := dynamodb.Unmarshal(buf.Bytes(), out); err != nil {
+		t.Fatal(err)
+	}
+	if !out.Expires.Equal(in.Expires) || out.Renew <= 59*time.Minute || out.Renew > time.Hour {
+		t.Error("got", out)
+	}
+}
+
+func TestParseTag(t *testing.T) {
+	cases := []struct {
+		tag     string
+		name    string
+		keyType string
+		skip    bool
+	}{
+		{`ddb:"id,HASH" json:"id"`, "id", "HASH", false},
+		{`json:"id" ddb:",RANGE"`, "", "RANGE", false},
+		{`ddb:"-"`, "", "", true},
+		{`json:"-"`, "", "", false},
+		{`ddb:"name"`, "name", "", false},
+		{`ddb:"email,gsi=ByEmail:HASH"`, "email", "", false},
+		{`ddb:"id,HASH,lsi=ByTime:RANGE"`, "id", "HASH", false},
+		{`ddb:"at,gsi=ByTeam:RANGE,RANGE"`, "at", "RANGE", false},
+	}
+	for _, c := range cases {
+		name, keyType, skip := parseTag(c.tag)
+		if name != c.name || keyType != c.keyType || skip != c.skip {
+			t.Error("tag ", c.tag)
+			t.Error("want", c.name, c.keyType, c.skip)
+			t.Error("got ", name, keyType, skip)
+		}
+	}
+}
+
+// TestTableParity checks that generated types send the same
+// payloads as their reflected counterparts for every Table
+// operation.
+func TestTableParity(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		w.Write([]byte(`{"Item":{}}`))
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+	client := dynamodb.Dial(dynamodb.EndPoint("Test", "local", u.Host, false), dynamodb.Auth("key", "secret"), nil)
+	session := client.Table("Test").Session(context.Background())
+
+	operations := map[string]func(item interface{}){
+		"Get":    func(item interface{}) { session.Get(item, true) },
+		"Delete": func(item interface{}) { session.Delete(item) },
+		"Put":    func(item interface{}) { session.Put(item) },
+		"PutIf":  func(item interface{}) { session.PutIf(item, item) },
+		"Add":    func(item interface{}) { session.Add(item) },
+	}
+	for name, operation := range operations {
+		bodies = nil
+		operation(testTaggedModel)
+		operation((*reflectTaggedModel)(testTaggedModel))
+		if len(bodies) != 2 || bodies[0] != bodies[1] {
+			t.Error(name)
+			t.Error("want", bodies[1])
+			t.Error("got ", bodies[0])
+		}
+	}
+}
+
+func TestRepository(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		switch r.Header.Get("X-Amz-Target") {
+		case "DynamoDB_20120810.GetItem":
+			w.Write([]byte(`{"Item":{"id":{"S":"id-1"},"count":{"N":"3"}}}`))
+		case "DynamoDB_20120810.Query":
+			w.Write([]byte(`{"Count":2,"Items":[{"id":{"S":"id-1"},"count":{"N":"1"}},{"id":{"S":"id-1"},"count":{"N":"2"}}]}`))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+	client := dynamodb.Dial(dynamodb.EndPoint("Test", "local", u.Host, false), dynamodb.Auth("key", "secret"), nil)
+	models := NewTaggedModelTable(client.Table("Test"))
+	ctx := context.Background()
+
+	created := time.Unix(0, 1)
+	item, err := models.Get(ctx, "id-1", created, true)
+	if err != nil || item.ID != "id-1" || item.Count != 3 || !item.Created.Equal(created) {
+		t.Error("got", item, err)
+	}
+	if !strings.Contains(bodies[0], `"ConsistentRead":true`) {
+		t.Error("got", bodies[0])
+	}
+	if err := models.Put(ctx, item); err != nil {
+		t.Error(err)
+	}
+	if err := models.Delete(ctx, "id-1", created); err != nil {
+		t.Error(err)
+	}
+	if !strings.Contains(bodies[2], `"Key":{"id":{"S":"id-1"},"Created":{"N":"1"}}`) {
+		t.Error("got", bodies[2])
+	}
+	items, err := models.Query(ctx, "id-1")
+	if err != nil || len(items) != 2 || items[1].Count != 2 {
+		t.Error("got", items, err)
+	}
+	if !strings.Contains(bodies[3], `"id":{"ComparisonOperator":"EQ", "AttributeValueList":[{"S":"id-1"}]}`) {
+		t.Error("got", bodies[3])
+	}
+}
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "dynamodb-marshal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, src := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestPackageMode(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.go": "package models\n\n//dynamodb:generate\ntype A struct {\n\tName string\n}\n\ntype Skipped struct {\n\tName string\n}\n",
+		"b.go": "package models\n\n// B is selected too.\n//dynamodb:generate\ntype B struct {\n\tCount int\n}\n",
+	})
+	defer os.RemoveAll(dir)
+
+	if err := run([]string{dir}, &options{}); err != nil {
+		t.Fatal(err)
+	}
+	a, _ := ioutil.ReadFile(filepath.Join(dir, "a_marshal.go"))
+	b, _ := ioutil.ReadFile(filepath.Join(dir, "b_marshal.go"))
+	helpers, _ := ioutil.ReadFile(filepath.Join(dir, helpersFile))
+	if !strings.Contains(string(a), "func (a *A) Encode(") || strings.Contains(string(a), "Skipped") {
+		t.Error("a_marshal.go:", string(a))
+	}
+	if !strings.Contains(string(b), "func (b *B) Encode(") {
+		t.Error("b_marshal.go:", string(b))
+	}
+	for _, src := range [][]byte{a, b} {
+		if strings.Contains(string(src), "func toJSON") {
+			t.Error("helpers duplicated in", string(src))
+		}
+	}
+	if !strings.Contains(string(helpers), "func toJSON") {
+		t.Error("helpers:", string(helpers))
+	}
+
+	// generated files are overwritten and -type overrides markers
+	if err := run([]string{dir}, &options{names: map[string]bool{"Skipped": true}}); err != nil {
+		t.Fatal(err)
+	}
+	a, _ = ioutil.ReadFile(filepath.Join(dir, "a_marshal.go"))
+	if !strings.Contains(string(a), "func (s *Skipped) Encode(") || strings.Contains(string(a), "func (a *A)") {
+		t.Error("a_marshal.go:", string(a))
+	}
+	if err := run([]string{dir}, &options{names: map[string]bool{"Missing": true}}); err == nil {
+		t.Error("want error for missing type")
+	}
+}
+
+func TestFileMode(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.go":         "package models\n\ntype A struct {\n\tName string\n}\n",
+		"a_marshal.go": "package models\n\n// hand-written\n",
+	})
+	defer os.RemoveAll(dir)
+
+	if err := run([]string{filepath.Join(dir, "a.go")}, &options{}); err == nil {
+		t.Error("want error when overwriting a file which wasn't generated")
+	}
+	if err := run([]string{filepath.Join(dir, "a.go")}, &options{force: true}); err != nil {
+		t.Fatal(err)
+	}
+	a, _ := ioutil.ReadFile(filepath.Join(dir, "a_marshal.go"))
+	if !strings.Contains(string(a), "func (a *A) Encode(") {
+		t.Error("a_marshal.go:", string(a))
+	}
+}
+
+func TestErrors(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.go": "package models\n\ntype A struct {\n\tOK   string\n\tChan chan int\n\tFunc func()\n}\n",
+	})
+	defer os.RemoveAll(dir)
+
+	err := run([]string{filepath.Join(dir, "a.go")}, &options{})
+	list, ok := err.(errorList)
+	if !ok || len(list) != 2 {
+		t.Fatal("got", err)
+	}
+	if !strings.HasSuffix(list[0].Error(), "a.go:5:2: unsupported type chan int for field A.Chan") {
+		t.Error("got", list[0])
+	}
+	if !strings.HasSuffix(list[1].Error(), "a.go:6:2: unsupported type func() for field A.Func") {
+		t.Error("got", list[1])
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a_marshal.go")); err == nil {
+		t.Error("files written despite errors")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.go": "package models\n\ntype A struct {\n\tName string\n}\n",
+	})
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "a.go")
+	if err := run([]string{path}, &options{check: true}); err == nil {
+		t.Error("want error before generating")
+	}
+	if err := run([]string{path}, &options{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := run([]string{path}, &options{check: true}); err != nil {
+		t.Error(err)
+	}
+	ioutil.WriteFile(path, []byte("package models\n\ntype A struct {\n\tName  string\n\tCount int\n}\n"), 0644)
+	err := run([]string{path}, &options{check: true})
+	if err == nil || !strings.Contains(err.Error(), "a_marshal.go: out of date") {
+		t.Error("got", err)
+	}
+}
+
+// TestCheckModes checks the output of each mode against the
+// other, nested structs being declared before the types using
+// them.
+func TestCheckModes(t *testing.T) {
+	src := "package models\n\ntype Address struct {\n\tCity string\n}\n\n//dynamodb:generate\ntype User struct {\n\tName    string\n\tAddress Address\n}\n"
+	dir := writeFiles(t, map[string]string{"user.go": src})
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "user.go")
+
+	if err := run([]string{dir}, &options{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := run([]string{path}, &options{check: true}); err != nil {
+		t.Error("file mode:", err)
+	}
+	os.Remove(filepath.Join(dir, "user_marshal.go"))
+	if err := run([]string{path}, &options{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := run([]string{dir}, &options{check: true}); err != nil {
+		t.Error("package mode:", err)
+	}
+}
+
+// TestGenerated fails when the generated files in this
+// directory are out of date.
+func TestGenerated(t *testing.T) {
+	if err := run([]string{"."}, &options{check: true, repo: true}); err != nil {
+		t.Error(err)
+	}
+}
@@ -2,6 +2,9 @@ package main
 
 import "time"
 
+//go:generate go run . -repo
+
+//dynamodb:generate
 type Model struct {
 	Bool bool
 	// BoolSlice   []bool
@@ -13,3 +16,50 @@ type Model struct {
 	StringSlice []string
 	Time        time.Time
 }
+
+//dynamodb:generate
+type TaggedModel struct {
+	ID          string    `ddb:"id,HASH" json:"id"`
+	Created     time.Time `ddb:",RANGE"`
+	Secret      string    `ddb:"-"`
+	Count       uint      `json:"count" ddb:"count"`
+	Total       uint64    `ddb:"total"`
+	Int64       int64
+	Uints       []uint   `ddb:"uints"`
+	Uint64s     []uint64 `ddb:"uint64s"`
+	Int64s      []int64  `ddb:"int64s"`
+	Description string   `json:"description"`
+	internal    string
+}
+
+// Status is encoded like its underlying type.
+type Status string
+
+// Address isn't marked but its methods are generated since
+// NestedModel uses it.
+type Address struct {
+	Street string
+	Zip    int
+}
+
+//dynamodb:generate
+type NestedModel struct {
+	ID       string `ddb:"id,HASH"`
+	Status   Status
+	Statuses []Status
+	Home     Address
+	Work     *Address
+	Previous []Address
+	Labels   map[string]string
+	Scores   map[Status][]int
+	Parent   *NestedModel
+	Note     *string
+	Created  []time.Time
+}
+
+//dynamodb:generate
+type SessionModel struct {
+	Token   string        `ddb:"token,HASH"`
+	Expires time.Time     `ddb:"expires,ttl"`
+	Renew   time.Duration `ddb:"renew,ttl"`
+}
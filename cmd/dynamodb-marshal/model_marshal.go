@@ -1,14 +1,14 @@
-// DO NOT EDIT.
-// Auto-generated template file by dynamodb-marshal.
+// Code generated by dynamodb-marshal. DO NOT EDIT.
 
 package main
 
 import (
 	"bytes"
 	"encoding/base64"
+	"github.com/groupme/dynamodb-1"
+	"golang.org/x/net/context"
 	"strconv"
 	"time"
-	"unicode/utf8"
 )
 
 func (m *Model) Encode(buf *bytes.Buffer) {
@@ -59,6 +59,54 @@ func (m *Model) Encode(buf *bytes.Buffer) {
 	buf.WriteString(`"}}`)
 }
 
+func (m *Model) EncodeExpected(buf *bytes.Buffer) {
+	buf.WriteString(`{"Bool":{"Value":{"N":"`)
+	if m.Bool {
+		buf.WriteByte('1')
+	} else {
+		buf.WriteByte('0')
+	}
+	buf.WriteString(`"}},"Byte":{"Value":{"B":"`)
+	buf.WriteString(base64.StdEncoding.EncodeToString(m.Byte))
+	buf.WriteString(`"}},"ByteSlice":{"Value":{"BS":[`)
+	for idx, elem := range m.ByteSlice {
+		buf.WriteByte('"')
+		buf.WriteString(base64.StdEncoding.EncodeToString(elem))
+		if idx == len(m.ByteSlice)-1 {
+			buf.WriteByte('"')
+		} else {
+			buf.WriteString(`",`)
+		}
+	}
+	buf.WriteString(`]}},"Int":{"Value":{"N":"`)
+	buf.WriteString(strconv.FormatInt(int64(m.Int), 10))
+	buf.WriteString(`"}},"IntSlice":{"Value":{"NS":[`)
+	for idx, elem := range m.IntSlice {
+		buf.WriteByte('"')
+		buf.WriteString(strconv.FormatInt(int64(elem), 10))
+		if idx == len(m.IntSlice)-1 {
+			buf.WriteByte('"')
+		} else {
+			buf.WriteString(`",`)
+		}
+	}
+	buf.WriteString(`]}},"String":{"Value":{"S":"`)
+	toJSON(m.String, buf)
+	buf.WriteString(`"}},"StringSlice":{"Value":{"SS":[`)
+	for idx, elem := range m.StringSlice {
+		buf.WriteByte('"')
+		toJSON(elem, buf)
+		if idx == len(m.StringSlice)-1 {
+			buf.WriteByte('"')
+		} else {
+			buf.WriteString(`",`)
+		}
+	}
+	buf.WriteString(`]}},"Time":{"Value":{"N":"`)
+	buf.WriteString(strconv.FormatInt(m.Time.UnixNano(), 10))
+	buf.WriteString(`"}}}`)
+}
+
 func (m *Model) Decode(data map[string]map[string]interface{}) {
 	if val, ok := data["Bool"]["N"].(string); ok {
 		if val == "1" {
@@ -103,54 +151,998 @@ func (m *Model) Decode(data map[string]map[string]interface{}) {
 	}
 }
 
+func (m *Model) DecodeItem(dec *dynamodb.Decoder) {
+	for dec.Next() {
+		switch string(dec.Name()) {
+		case "Bool":
+			if dec.Attr("N") {
+				m.Bool = dec.Bool()
+				dec.End()
+			}
+		case "Byte":
+			if dec.Attr("B") {
+				m.Byte = dec.Bytes()
+				dec.End()
+			}
+		case "ByteSlice":
+			if dec.Attr("BS") {
+				for dec.NextElem() {
+					m.ByteSlice = append(m.ByteSlice, dec.Bytes())
+				}
+				dec.End()
+			}
+		case "Int":
+			if dec.Attr("N") {
+				m.Int = int(dec.Int())
+				dec.End()
+			}
+		case "IntSlice":
+			if dec.Attr("NS") {
+				for dec.NextElem() {
+					m.IntSlice = append(m.IntSlice, int(dec.Int()))
+				}
+				dec.End()
+			}
+		case "String":
+			if dec.Attr("S") {
+				m.String = dec.String()
+				dec.End()
+			}
+		case "StringSlice":
+			if dec.Attr("SS") {
+				for dec.NextElem() {
+					m.StringSlice = append(m.StringSlice, dec.String())
+				}
+				dec.End()
+			}
+		case "Time":
+			if dec.Attr("N") {
+				m.Time = time.Unix(0, dec.Int()).UTC()
+				dec.End()
+			}
+		default:
+			dec.Skip()
+		}
+	}
+}
+
+func (t *TaggedModel) Encode(buf *bytes.Buffer) {
+	buf.WriteString(`{"id":{"S":"`)
+	toJSON(t.ID, buf)
+	buf.WriteString(`"},"Created":{"N":"`)
+	buf.WriteString(strconv.FormatInt(t.Created.UnixNano(), 10))
+	buf.WriteString(`"},"count":{"N":"`)
+	buf.WriteString(strconv.FormatUint(uint64(t.Count), 10))
+	buf.WriteString(`"},"total":{"N":"`)
+	buf.WriteString(strconv.FormatUint(t.Total, 10))
+	buf.WriteString(`"},"Int64":{"N":"`)
+	buf.WriteString(strconv.FormatInt(t.Int64, 10))
+	buf.WriteString(`"},"uints":{"NS":[`)
+	for idx, elem := range t.Uints {
+		buf.WriteByte('"')
+		buf.WriteString(strconv.FormatUint(uint64(elem), 10))
+		if idx == len(t.Uints)-1 {
+			buf.WriteByte('"')
+		} else {
+			buf.WriteString(`",`)
+		}
+	}
+	buf.WriteString(`]},"uint64s":{"NS":[`)
+	for idx, elem := range t.Uint64s {
+		buf.WriteByte('"')
+		buf.WriteString(strconv.FormatUint(elem, 10))
+		if idx == len(t.Uint64s)-1 {
+			buf.WriteByte('"')
+		} else {
+			buf.WriteString(`",`)
+		}
+	}
+	buf.WriteString(`]},"int64s":{"NS":[`)
+	for idx, elem := range t.Int64s {
+		buf.WriteByte('"')
+		buf.WriteString(strconv.FormatInt(elem, 10))
+		if idx == len(t.Int64s)-1 {
+			buf.WriteByte('"')
+		} else {
+			buf.WriteString(`",`)
+		}
+	}
+	buf.WriteString(`]},"Description":{"S":"`)
+	toJSON(t.Description, buf)
+	buf.WriteString(`"}}`)
+}
+
+func (t *TaggedModel) EncodeKey(buf *bytes.Buffer) {
+	buf.WriteString(`{"id":{"S":"`)
+	toJSON(t.ID, buf)
+	buf.WriteString(`"},"Created":{"N":"`)
+	buf.WriteString(strconv.FormatInt(t.Created.UnixNano(), 10))
+	buf.WriteString(`"}}`)
+}
+
+func (t *TaggedModel) EncodeExpected(buf *bytes.Buffer) {
+	buf.WriteString(`{"id":{"Value":{"S":"`)
+	toJSON(t.ID, buf)
+	buf.WriteString(`"}},"Created":{"Value":{"N":"`)
+	buf.WriteString(strconv.FormatInt(t.Created.UnixNano(), 10))
+	buf.WriteString(`"}},"count":{"Value":{"N":"`)
+	buf.WriteString(strconv.FormatUint(uint64(t.Count), 10))
+	buf.WriteString(`"}},"total":{"Value":{"N":"`)
+	buf.WriteString(strconv.FormatUint(t.Total, 10))
+	buf.WriteString(`"}},"Int64":{"Value":{"N":"`)
+	buf.WriteString(strconv.FormatInt(t.Int64, 10))
+	buf.WriteString(`"}},"uints":{"Value":{"NS":[`)
+	for idx, elem := range t.Uints {
+		buf.WriteByte('"')
+		buf.WriteString(strconv.FormatUint(uint64(elem), 10))
+		if idx == len(t.Uints)-1 {
+			buf.WriteByte('"')
+		} else {
+			buf.WriteString(`",`)
+		}
+	}
+	buf.WriteString(`]}},"uint64s":{"Value":{"NS":[`)
+	for idx, elem := range t.Uint64s {
+		buf.WriteByte('"')
+		buf.WriteString(strconv.FormatUint(elem, 10))
+		if idx == len(t.Uint64s)-1 {
+			buf.WriteByte('"')
+		} else {
+			buf.WriteString(`",`)
+		}
+	}
+	buf.WriteString(`]}},"int64s":{"Value":{"NS":[`)
+	for idx, elem := range t.Int64s {
+		buf.WriteByte('"')
+		buf.WriteString(strconv.FormatInt(elem, 10))
+		if idx == len(t.Int64s)-1 {
+			buf.WriteByte('"')
+		} else {
+			buf.WriteString(`",`)
+		}
+	}
+	buf.WriteString(`]}},"Description":{"Value":{"S":"`)
+	toJSON(t.Description, buf)
+	buf.WriteString(`"}}}`)
+}
 
-// Adapted from the encoding/json package in the standard
-// library.
-const hex = "0123456789abcdef"
+func (t *TaggedModel) Decode(data map[string]map[string]interface{}) {
+	if val, ok := data["id"]["S"].(string); ok {
+		t.ID = val
+	}
+	if val, ok := data["Created"]["N"].(string); ok {
+		tmp, _ := strconv.ParseInt(val, 10, 64)
+		t.Created = time.Unix(0, tmp).UTC()
+	}
+	if val, ok := data["count"]["N"].(string); ok {
+		tmp, _ := strconv.ParseUint(val, 10, 64)
+		t.Count = uint(tmp)
+	}
+	if val, ok := data["total"]["N"].(string); ok {
+		t.Total, _ = strconv.ParseUint(val, 10, 64)
+	}
+	if val, ok := data["Int64"]["N"].(string); ok {
+		t.Int64, _ = strconv.ParseInt(val, 10, 64)
+	}
+	if vals, ok := data["uints"]["NS"].([]interface{}); ok {
+		for _, sval := range vals {
+			val := sval.(string)
+			tmp, _ := strconv.ParseUint(val, 10, 64)
+			t.Uints = append(t.Uints, uint(tmp))
+		}
+	}
+	if vals, ok := data["uint64s"]["NS"].([]interface{}); ok {
+		for _, sval := range vals {
+			val := sval.(string)
+			tmp, _ := strconv.ParseUint(val, 10, 64)
+			t.Uint64s = append(t.Uint64s, tmp)
+		}
+	}
+	if vals, ok := data["int64s"]["NS"].([]interface{}); ok {
+		for _, sval := range vals {
+			val := sval.(string)
+			tmp, _ := strconv.ParseInt(val, 10, 64)
+			t.Int64s = append(t.Int64s, tmp)
+		}
+	}
+	if val, ok := data["Description"]["S"].(string); ok {
+		t.Description = val
+	}
+}
 
-func toJSON(s string, buf *bytes.Buffer) {
-	start := 0
-	for i := 0; i < len(s); {
-		if b := s[i]; b < utf8.RuneSelf {
-			if 0x20 <= b && b != '\\' && b != '"' && b != '<' && b != '>' && b != '&' {
-				i++
-				continue
+func (t *TaggedModel) DecodeItem(dec *dynamodb.Decoder) {
+	for dec.Next() {
+		switch string(dec.Name()) {
+		case "id":
+			if dec.Attr("S") {
+				t.ID = dec.String()
+				dec.End()
+			}
+		case "Created":
+			if dec.Attr("N") {
+				t.Created = time.Unix(0, dec.Int()).UTC()
+				dec.End()
+			}
+		case "count":
+			if dec.Attr("N") {
+				t.Count = uint(dec.Uint())
+				dec.End()
+			}
+		case "total":
+			if dec.Attr("N") {
+				t.Total = dec.Uint()
+				dec.End()
+			}
+		case "Int64":
+			if dec.Attr("N") {
+				t.Int64 = dec.Int()
+				dec.End()
+			}
+		case "uints":
+			if dec.Attr("NS") {
+				for dec.NextElem() {
+					t.Uints = append(t.Uints, uint(dec.Uint()))
+				}
+				dec.End()
 			}
-			if start < i {
-				buf.WriteString(s[start:i])
+		case "uint64s":
+			if dec.Attr("NS") {
+				for dec.NextElem() {
+					t.Uint64s = append(t.Uint64s, dec.Uint())
+				}
+				dec.End()
 			}
-			switch b {
-			case '\\', '"':
-				buf.WriteByte('\\')
-				buf.WriteByte(b)
-			case '\n':
-				buf.WriteByte('\\')
-				buf.WriteByte('n')
-			case '\r':
-				buf.WriteByte('\\')
-				buf.WriteByte('r')
-			default:
-				buf.WriteString("\\u00")
-				buf.WriteByte(hex[b>>4])
-				buf.WriteByte(hex[b&0xF])
+		case "int64s":
+			if dec.Attr("NS") {
+				for dec.NextElem() {
+					t.Int64s = append(t.Int64s, dec.Int())
+				}
+				dec.End()
 			}
-			i++
-			start = i
-			continue
+		case "Description":
+			if dec.Attr("S") {
+				t.Description = dec.String()
+				dec.End()
+			}
+		default:
+			dec.Skip()
 		}
-		c, size := utf8.DecodeRuneInString(s[i:])
-		if c == utf8.RuneError && size == 1 {
-			if start < i {
-				buf.WriteString(s[start:i])
+	}
+}
+
+// TaggedModelTable accesses a table of TaggedModel items.
+type TaggedModelTable struct {
+	table *dynamodb.Table
+}
+
+// NewTaggedModelTable wraps table.
+func NewTaggedModelTable(table *dynamodb.Table) *TaggedModelTable {
+	return &TaggedModelTable{table: table}
+}
+
+// Get fetches the item with the given key, with a strongly
+// consistent read if consistent is set.
+func (t *TaggedModelTable) Get(ctx context.Context, hashKey string, rangeKey time.Time, consistent bool) (*TaggedModel, error) {
+	item := &TaggedModel{ID: hashKey, Created: rangeKey}
+	if err := t.table.Get(ctx, item, consistent); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// Put puts item.
+func (t *TaggedModelTable) Put(ctx context.Context, item *TaggedModel) error {
+	return t.table.Put(ctx, item)
+}
+
+// Delete deletes the item with the given key.
+func (t *TaggedModelTable) Delete(ctx context.Context, hashKey string, rangeKey time.Time) error {
+	return t.table.Delete(ctx, &TaggedModel{ID: hashKey, Created: rangeKey})
+}
+
+// Query returns the items with the given hash key.
+func (t *TaggedModelTable) Query(ctx context.Context, hashKey string) ([]*TaggedModel, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteString(`{"S":"`)
+	toJSON(hashKey, buf)
+	buf.WriteString(`"}`)
+	var result []*TaggedModel
+	err := t.table.Query().Where("id", dynamodb.EQ, buf.Bytes()).Decode(ctx, false, func(dec *dynamodb.Decoder) {
+		item := &TaggedModel{}
+		item.DecodeItem(dec)
+		result = append(result, item)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (a *Address) Encode(buf *bytes.Buffer) {
+	buf.WriteString(`{"Street":{"S":"`)
+	toJSON(a.Street, buf)
+	buf.WriteString(`"},"Zip":{"N":"`)
+	buf.WriteString(strconv.FormatInt(int64(a.Zip), 10))
+	buf.WriteString(`"}}`)
+}
+
+func (a *Address) EncodeExpected(buf *bytes.Buffer) {
+	buf.WriteString(`{"Street":{"Value":{"S":"`)
+	toJSON(a.Street, buf)
+	buf.WriteString(`"}},"Zip":{"Value":{"N":"`)
+	buf.WriteString(strconv.FormatInt(int64(a.Zip), 10))
+	buf.WriteString(`"}}}`)
+}
+
+func (a *Address) Decode(data map[string]map[string]interface{}) {
+	if val, ok := data["Street"]["S"].(string); ok {
+		a.Street = val
+	}
+	if val, ok := data["Zip"]["N"].(string); ok {
+		tmp, _ := strconv.ParseInt(val, 10, 64)
+		a.Zip = int(tmp)
+	}
+}
+
+func (a *Address) DecodeItem(dec *dynamodb.Decoder) {
+	for dec.Next() {
+		switch string(dec.Name()) {
+		case "Street":
+			if dec.Attr("S") {
+				a.Street = dec.String()
+				dec.End()
 			}
-			buf.WriteString("\\ufffd")
-			i += size
-			start = i
-			continue
+		case "Zip":
+			if dec.Attr("N") {
+				a.Zip = int(dec.Int())
+				dec.End()
+			}
+		default:
+			dec.Skip()
 		}
-		i += size
 	}
-	if start < len(s) {
-		buf.WriteString(s[start:])
+}
+
+func (n *NestedModel) Encode(buf *bytes.Buffer) {
+	buf.WriteString(`{"id":{"S":"`)
+	toJSON(n.ID, buf)
+	buf.WriteString(`"},"Status":{"S":"`)
+	toJSON(string(n.Status), buf)
+	buf.WriteString(`"},"Statuses":{"SS":[`)
+	for idx, elem := range n.Statuses {
+		buf.WriteByte('"')
+		toJSON(string(elem), buf)
+		if idx == len(n.Statuses)-1 {
+			buf.WriteByte('"')
+		} else {
+			buf.WriteString(`",`)
+		}
+	}
+	buf.WriteString(`]},"Home":{"M":`)
+	n.Home.Encode(buf)
+	buf.WriteString(`},"Work":`)
+	if n.Work == nil {
+		buf.WriteString(`{"NULL":true}`)
+	} else {
+		buf.WriteString(`{"M":`)
+		n.Work.Encode(buf)
+		buf.WriteString(`}`)
+	}
+	buf.WriteString(`,"Previous":`)
+	if n.Previous == nil {
+		buf.WriteString(`{"NULL":true}`)
+	} else {
+		buf.WriteString(`{"L":[`)
+		for idx, elem := range n.Previous {
+			if idx > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(`{"M":`)
+			elem.Encode(buf)
+			buf.WriteString(`}`)
+		}
+		buf.WriteString(`]}`)
+	}
+	buf.WriteString(`,"Labels":`)
+	if n.Labels == nil {
+		buf.WriteString(`{"NULL":true}`)
+	} else {
+		buf.WriteString(`{"M":{`)
+		for key, elem := range n.Labels {
+			buf.WriteByte('"')
+			toJSON(key, buf)
+			buf.WriteString(`":{"S":"`)
+			toJSON(elem, buf)
+			buf.WriteString(`"},`)
+		}
+		if len(n.Labels) > 0 {
+			buf.Truncate(buf.Len() - 1)
+		}
+		buf.WriteString(`}}`)
+	}
+	buf.WriteString(`,"Scores":`)
+	if n.Scores == nil {
+		buf.WriteString(`{"NULL":true}`)
+	} else {
+		buf.WriteString(`{"M":{`)
+		for key, elem := range n.Scores {
+			buf.WriteByte('"')
+			toJSON(string(key), buf)
+			buf.WriteString(`":{"NS":[`)
+			for idx1, elem1 := range elem {
+				buf.WriteByte('"')
+				buf.WriteString(strconv.FormatInt(int64(elem1), 10))
+				if idx1 == len(elem)-1 {
+					buf.WriteByte('"')
+				} else {
+					buf.WriteString(`",`)
+				}
+			}
+			buf.WriteString(`]},`)
+		}
+		if len(n.Scores) > 0 {
+			buf.Truncate(buf.Len() - 1)
+		}
+		buf.WriteString(`}}`)
+	}
+	buf.WriteString(`,"Parent":`)
+	if n.Parent == nil {
+		buf.WriteString(`{"NULL":true}`)
+	} else {
+		buf.WriteString(`{"M":`)
+		n.Parent.Encode(buf)
+		buf.WriteString(`}`)
+	}
+	buf.WriteString(`,"Note":`)
+	if n.Note == nil {
+		buf.WriteString(`{"NULL":true}`)
+	} else {
+		buf.WriteString(`{"S":"`)
+		toJSON(*n.Note, buf)
+		buf.WriteString(`"}`)
+	}
+	buf.WriteString(`,"Created":`)
+	if n.Created == nil {
+		buf.WriteString(`{"NULL":true}`)
+	} else {
+		buf.WriteString(`{"L":[`)
+		for idx, elem := range n.Created {
+			if idx > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(`{"N":"`)
+			buf.WriteString(strconv.FormatInt(elem.UnixNano(), 10))
+			buf.WriteString(`"}`)
+		}
+		buf.WriteString(`]}`)
+	}
+	buf.WriteString(`}`)
+}
+
+func (n *NestedModel) EncodeKey(buf *bytes.Buffer) {
+	buf.WriteString(`{"id":{"S":"`)
+	toJSON(n.ID, buf)
+	buf.WriteString(`"}}`)
+}
+
+func (n *NestedModel) EncodeExpected(buf *bytes.Buffer) {
+	buf.WriteString(`{"id":{"Value":{"S":"`)
+	toJSON(n.ID, buf)
+	buf.WriteString(`"}},"Status":{"Value":{"S":"`)
+	toJSON(string(n.Status), buf)
+	buf.WriteString(`"}},"Statuses":{"Value":{"SS":[`)
+	for idx, elem := range n.Statuses {
+		buf.WriteByte('"')
+		toJSON(string(elem), buf)
+		if idx == len(n.Statuses)-1 {
+			buf.WriteByte('"')
+		} else {
+			buf.WriteString(`",`)
+		}
+	}
+	buf.WriteString(`]}},"Home":{"Value":{"M":`)
+	n.Home.Encode(buf)
+	buf.WriteString(`}},"Work":{"Value":`)
+	if n.Work == nil {
+		buf.WriteString(`{"NULL":true}`)
+	} else {
+		buf.WriteString(`{"M":`)
+		n.Work.Encode(buf)
+		buf.WriteString(`}`)
+	}
+	buf.WriteString(`},"Previous":{"Value":`)
+	if n.Previous == nil {
+		buf.WriteString(`{"NULL":true}`)
+	} else {
+		buf.WriteString(`{"L":[`)
+		for idx, elem := range n.Previous {
+			if idx > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(`{"M":`)
+			elem.Encode(buf)
+			buf.WriteString(`}`)
+		}
+		buf.WriteString(`]}`)
+	}
+	buf.WriteString(`},"Labels":{"Value":`)
+	if n.Labels == nil {
+		buf.WriteString(`{"NULL":true}`)
+	} else {
+		buf.WriteString(`{"M":{`)
+		for key, elem := range n.Labels {
+			buf.WriteByte('"')
+			toJSON(key, buf)
+			buf.WriteString(`":{"S":"`)
+			toJSON(elem, buf)
+			buf.WriteString(`"},`)
+		}
+		if len(n.Labels) > 0 {
+			buf.Truncate(buf.Len() - 1)
+		}
+		buf.WriteString(`}}`)
+	}
+	buf.WriteString(`},"Scores":{"Value":`)
+	if n.Scores == nil {
+		buf.WriteString(`{"NULL":true}`)
+	} else {
+		buf.WriteString(`{"M":{`)
+		for key, elem := range n.Scores {
+			buf.WriteByte('"')
+			toJSON(string(key), buf)
+			buf.WriteString(`":{"NS":[`)
+			for idx1, elem1 := range elem {
+				buf.WriteByte('"')
+				buf.WriteString(strconv.FormatInt(int64(elem1), 10))
+				if idx1 == len(elem)-1 {
+					buf.WriteByte('"')
+				} else {
+					buf.WriteString(`",`)
+				}
+			}
+			buf.WriteString(`]},`)
+		}
+		if len(n.Scores) > 0 {
+			buf.Truncate(buf.Len() - 1)
+		}
+		buf.WriteString(`}}`)
+	}
+	buf.WriteString(`},"Parent":{"Value":`)
+	if n.Parent == nil {
+		buf.WriteString(`{"NULL":true}`)
+	} else {
+		buf.WriteString(`{"M":`)
+		n.Parent.Encode(buf)
+		buf.WriteString(`}`)
+	}
+	buf.WriteString(`},"Note":{"Value":`)
+	if n.Note == nil {
+		buf.WriteString(`{"NULL":true}`)
+	} else {
+		buf.WriteString(`{"S":"`)
+		toJSON(*n.Note, buf)
+		buf.WriteString(`"}`)
+	}
+	buf.WriteString(`},"Created":{"Value":`)
+	if n.Created == nil {
+		buf.WriteString(`{"NULL":true}`)
+	} else {
+		buf.WriteString(`{"L":[`)
+		for idx, elem := range n.Created {
+			if idx > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(`{"N":"`)
+			buf.WriteString(strconv.FormatInt(elem.UnixNano(), 10))
+			buf.WriteString(`"}`)
+		}
+		buf.WriteString(`]}`)
+	}
+	buf.WriteString(`}}`)
+}
+
+func (n *NestedModel) Decode(data map[string]map[string]interface{}) {
+	if val, ok := data["id"]["S"].(string); ok {
+		n.ID = val
+	}
+	if val, ok := data["Status"]["S"].(string); ok {
+		n.Status = Status(val)
+	}
+	if vals, ok := data["Statuses"]["SS"].([]interface{}); ok {
+		for _, sval := range vals {
+			val := sval.(string)
+			n.Statuses = append(n.Statuses, Status(val))
+		}
+	}
+	if av, ok := data["Home"]; ok {
+		if m1, ok := av["M"].(map[string]interface{}); ok {
+			n.Home.Decode(toItem(m1))
+		}
+	}
+	if av, ok := data["Work"]; ok {
+		if _, ok := av["NULL"]; ok {
+			n.Work = nil
+		} else {
+			p1 := new(Address)
+			if m2, ok := av["M"].(map[string]interface{}); ok {
+				p1.Decode(toItem(m2))
+			}
+			n.Work = p1
+		}
+	}
+	if av, ok := data["Previous"]; ok {
+		if _, ok := av["NULL"]; ok {
+			n.Previous = nil
+		} else if l1, ok := av["L"].([]interface{}); ok {
+			n.Previous = make([]Address, 0, len(l1))
+			for _, raw1 := range l1 {
+				av1, _ := raw1.(map[string]interface{})
+				var elem1 Address
+				if m2, ok := av1["M"].(map[string]interface{}); ok {
+					elem1.Decode(toItem(m2))
+				}
+				n.Previous = append(n.Previous, elem1)
+			}
+		}
+	}
+	if av, ok := data["Labels"]; ok {
+		if _, ok := av["NULL"]; ok {
+			n.Labels = nil
+		} else if m1, ok := av["M"].(map[string]interface{}); ok {
+			n.Labels = make(map[string]string, len(m1))
+			for key1, raw1 := range m1 {
+				av1, _ := raw1.(map[string]interface{})
+				var elem1 string
+				if val, ok := av1["S"].(string); ok {
+					elem1 = val
+				}
+				n.Labels[key1] = elem1
+			}
+		}
+	}
+	if av, ok := data["Scores"]; ok {
+		if _, ok := av["NULL"]; ok {
+			n.Scores = nil
+		} else if m1, ok := av["M"].(map[string]interface{}); ok {
+			n.Scores = make(map[Status][]int, len(m1))
+			for key1, raw1 := range m1 {
+				av1, _ := raw1.(map[string]interface{})
+				var elem1 []int
+				if vals, ok := av1["NS"].([]interface{}); ok {
+					for _, sval := range vals {
+						val := sval.(string)
+						tmp, _ := strconv.ParseInt(val, 10, 64)
+						elem1 = append(elem1, int(tmp))
+					}
+				}
+				n.Scores[Status(key1)] = elem1
+			}
+		}
+	}
+	if av, ok := data["Parent"]; ok {
+		if _, ok := av["NULL"]; ok {
+			n.Parent = nil
+		} else {
+			p1 := new(NestedModel)
+			if m2, ok := av["M"].(map[string]interface{}); ok {
+				p1.Decode(toItem(m2))
+			}
+			n.Parent = p1
+		}
+	}
+	if av, ok := data["Note"]; ok {
+		if _, ok := av["NULL"]; ok {
+			n.Note = nil
+		} else {
+			p1 := new(string)
+			if val, ok := av["S"].(string); ok {
+				*p1 = val
+			}
+			n.Note = p1
+		}
+	}
+	if av, ok := data["Created"]; ok {
+		if _, ok := av["NULL"]; ok {
+			n.Created = nil
+		} else if l1, ok := av["L"].([]interface{}); ok {
+			n.Created = make([]time.Time, 0, len(l1))
+			for _, raw1 := range l1 {
+				av1, _ := raw1.(map[string]interface{})
+				var elem1 time.Time
+				if val, ok := av1["N"].(string); ok {
+					tmp, _ := strconv.ParseInt(val, 10, 64)
+					elem1 = time.Unix(0, tmp).UTC()
+				}
+				n.Created = append(n.Created, elem1)
+			}
+		}
+	}
+}
+
+func (n *NestedModel) DecodeItem(dec *dynamodb.Decoder) {
+	for dec.Next() {
+		switch string(dec.Name()) {
+		case "id":
+			if dec.Attr("S") {
+				n.ID = dec.String()
+				dec.End()
+			}
+		case "Status":
+			if dec.Attr("S") {
+				n.Status = Status(dec.String())
+				dec.End()
+			}
+		case "Statuses":
+			if dec.Attr("SS") {
+				for dec.NextElem() {
+					n.Statuses = append(n.Statuses, Status(dec.String()))
+				}
+				dec.End()
+			}
+		case "Home":
+			if dec.Attr("M") {
+				dec.Decode(&n.Home)
+				dec.End()
+			}
+		case "Work":
+			if dec.Null() {
+				n.Work = nil
+			} else {
+				p1 := new(Address)
+				if dec.Attr("M") {
+					dec.Decode(p1)
+					dec.End()
+				}
+				n.Work = p1
+			}
+		case "Previous":
+			if dec.Null() {
+				n.Previous = nil
+			} else if dec.Attr("L") {
+				n.Previous = make([]Address, 0)
+				for dec.NextElem() {
+					var elem1 Address
+					if dec.Attr("M") {
+						dec.Decode(&elem1)
+						dec.End()
+					}
+					n.Previous = append(n.Previous, elem1)
+				}
+				dec.End()
+			}
+		case "Labels":
+			if dec.Null() {
+				n.Labels = nil
+			} else if dec.Attr("M") {
+				n.Labels = make(map[string]string)
+				for dec.Next() {
+					key1 := string(dec.Name())
+					var elem1 string
+					if dec.Attr("S") {
+						elem1 = dec.String()
+						dec.End()
+					}
+					n.Labels[key1] = elem1
+				}
+				dec.End()
+			}
+		case "Scores":
+			if dec.Null() {
+				n.Scores = nil
+			} else if dec.Attr("M") {
+				n.Scores = make(map[Status][]int)
+				for dec.Next() {
+					key1 := Status(dec.Name())
+					var elem1 []int
+					if dec.Attr("NS") {
+						for dec.NextElem() {
+							elem1 = append(elem1, int(dec.Int()))
+						}
+						dec.End()
+					}
+					n.Scores[key1] = elem1
+				}
+				dec.End()
+			}
+		case "Parent":
+			if dec.Null() {
+				n.Parent = nil
+			} else {
+				p1 := new(NestedModel)
+				if dec.Attr("M") {
+					dec.Decode(p1)
+					dec.End()
+				}
+				n.Parent = p1
+			}
+		case "Note":
+			if dec.Null() {
+				n.Note = nil
+			} else {
+				p1 := new(string)
+				if dec.Attr("S") {
+					*p1 = dec.String()
+					dec.End()
+				}
+				n.Note = p1
+			}
+		case "Created":
+			if dec.Null() {
+				n.Created = nil
+			} else if dec.Attr("L") {
+				n.Created = make([]time.Time, 0)
+				for dec.NextElem() {
+					var elem1 time.Time
+					if dec.Attr("N") {
+						elem1 = time.Unix(0, dec.Int()).UTC()
+						dec.End()
+					}
+					n.Created = append(n.Created, elem1)
+				}
+				dec.End()
+			}
+		default:
+			dec.Skip()
+		}
+	}
+}
+
+// NestedModelTable accesses a table of NestedModel items.
+type NestedModelTable struct {
+	table *dynamodb.Table
+}
+
+// NewNestedModelTable wraps table.
+func NewNestedModelTable(table *dynamodb.Table) *NestedModelTable {
+	return &NestedModelTable{table: table}
+}
+
+// Get fetches the item with the given key, with a strongly
+// consistent read if consistent is set.
+func (t *NestedModelTable) Get(ctx context.Context, hashKey string, consistent bool) (*NestedModel, error) {
+	item := &NestedModel{ID: hashKey}
+	if err := t.table.Get(ctx, item, consistent); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// Put puts item.
+func (t *NestedModelTable) Put(ctx context.Context, item *NestedModel) error {
+	return t.table.Put(ctx, item)
+}
+
+// Delete deletes the item with the given key.
+func (t *NestedModelTable) Delete(ctx context.Context, hashKey string) error {
+	return t.table.Delete(ctx, &NestedModel{ID: hashKey})
+}
+
+// Query returns the items with the given hash key.
+func (t *NestedModelTable) Query(ctx context.Context, hashKey string) ([]*NestedModel, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteString(`{"S":"`)
+	toJSON(hashKey, buf)
+	buf.WriteString(`"}`)
+	var result []*NestedModel
+	err := t.table.Query().Where("id", dynamodb.EQ, buf.Bytes()).Decode(ctx, false, func(dec *dynamodb.Decoder) {
+		item := &NestedModel{}
+		item.DecodeItem(dec)
+		result = append(result, item)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *SessionModel) Encode(buf *bytes.Buffer) {
+	buf.WriteString(`{"token":{"S":"`)
+	toJSON(s.Token, buf)
+	buf.WriteString(`"},"expires":{"N":"`)
+	buf.WriteString(strconv.FormatInt(dynamodb.EncodeTTL(s.Expires), 10))
+	buf.WriteString(`"},"renew":{"N":"`)
+	buf.WriteString(strconv.FormatInt(dynamodb.EncodeTTLDuration(s.Renew), 10))
+	buf.WriteString(`"}}`)
+}
+
+func (s *SessionModel) EncodeKey(buf *bytes.Buffer) {
+	buf.WriteString(`{"token":{"S":"`)
+	toJSON(s.Token, buf)
+	buf.WriteString(`"}}`)
+}
+
+func (s *SessionModel) EncodeExpected(buf *bytes.Buffer) {
+	buf.WriteString(`{"token":{"Value":{"S":"`)
+	toJSON(s.Token, buf)
+	buf.WriteString(`"}},"expires":{"Value":{"N":"`)
+	buf.WriteString(strconv.FormatInt(dynamodb.EncodeTTL(s.Expires), 10))
+	buf.WriteString(`"}},"renew":{"Value":{"N":"`)
+	buf.WriteString(strconv.FormatInt(dynamodb.EncodeTTLDuration(s.Renew), 10))
+	buf.WriteString(`"}}}`)
+}
+
+func (s *SessionModel) Decode(data map[string]map[string]interface{}) {
+	if val, ok := data["token"]["S"].(string); ok {
+		s.Token = val
+	}
+	if val, ok := data["expires"]["N"].(string); ok {
+		tmp, _ := strconv.ParseInt(val, 10, 64)
+		s.Expires = dynamodb.DecodeTTL(tmp)
+	}
+	if val, ok := data["renew"]["N"].(string); ok {
+		tmp, _ := strconv.ParseInt(val, 10, 64)
+		s.Renew = dynamodb.DecodeTTLDuration(tmp)
+	}
+}
+
+func (s *SessionModel) DecodeItem(dec *dynamodb.Decoder) {
+	for dec.Next() {
+		switch string(dec.Name()) {
+		case "token":
+			if dec.Attr("S") {
+				s.Token = dec.String()
+				dec.End()
+			}
+		case "expires":
+			if dec.Attr("N") {
+				s.Expires = dynamodb.DecodeTTL(dec.Int())
+				dec.End()
+			}
+		case "renew":
+			if dec.Attr("N") {
+				s.Renew = dynamodb.DecodeTTLDuration(dec.Int())
+				dec.End()
+			}
+		default:
+			dec.Skip()
+		}
+	}
+}
+
+// SessionModelTable accesses a table of SessionModel items.
+type SessionModelTable struct {
+	table *dynamodb.Table
+}
+
+// NewSessionModelTable wraps table.
+func NewSessionModelTable(table *dynamodb.Table) *SessionModelTable {
+	return &SessionModelTable{table: table}
+}
+
+// Get fetches the item with the given key, with a strongly
+// consistent read if consistent is set.
+func (t *SessionModelTable) Get(ctx context.Context, hashKey string, consistent bool) (*SessionModel, error) {
+	item := &SessionModel{Token: hashKey}
+	if err := t.table.Get(ctx, item, consistent); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// Put puts item.
+func (t *SessionModelTable) Put(ctx context.Context, item *SessionModel) error {
+	return t.table.Put(ctx, item)
+}
+
+// Delete deletes the item with the given key.
+func (t *SessionModelTable) Delete(ctx context.Context, hashKey string) error {
+	return t.table.Delete(ctx, &SessionModel{Token: hashKey})
+}
+
+// Query returns the items with the given hash key.
+func (t *SessionModelTable) Query(ctx context.Context, hashKey string) ([]*SessionModel, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteString(`{"S":"`)
+	toJSON(hashKey, buf)
+	buf.WriteString(`"}`)
+	var result []*SessionModel
+	err := t.table.Query().Where("token", dynamodb.EQ, buf.Bytes()).Decode(ctx, false, func(dec *dynamodb.Decoder) {
+		item := &SessionModel{}
+		item.DecodeItem(dec)
+		result = append(result, item)
+	})
+	if err != nil {
+		return nil, err
 	}
+	return result, nil
 }
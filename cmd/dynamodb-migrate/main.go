@@ -0,0 +1,134 @@
+// Command dynamodb-migrate reconciles DynamoDB tables with the
+// schema described in a JSON spec file, e.g.
+//
+//     [{
+//         "TableName": "users",
+//         "KeySchema": [{"AttributeName": "id", "KeyType": "HASH"}],
+//         "AttributeDefinitions": [
+//             {"AttributeName": "id", "AttributeType": "S"},
+//             {"AttributeName": "email", "AttributeType": "S"}
+//         ],
+//         "BillingMode": "PAY_PER_REQUEST",
+//         "GlobalIndexes": [{
+//             "IndexName": "ByEmail",
+//             "KeySchema": [{"AttributeName": "email", "KeyType": "HASH"}],
+//             "Projection": {"ProjectionType": "KEYS_ONLY"}
+//         }],
+//         "Stream": {"StreamEnabled": true, "StreamViewType": "NEW_IMAGE"},
+//         "TimeToLive": {"Enabled": true, "AttributeName": "expires"},
+//         "Tags": [{"Key": "team", "Value": "core"}]
+//     }]
+//
+// The spec holds a migrate.Spec, or a list of them, as encoded
+// by encoding/json. By default the steps planned for each table
+// are only printed:
+//
+//     $ dynamodb-migrate -region eu-west-1 tables.json
+//     users: create index ByEmail
+//     users: enable time to live on expires
+//
+// With -apply they are applied one by one, waiting for each
+// table to become active again in between. The credentials are
+// read from the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY
+// environment variables.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"time"
+
+	"github.com/groupme/dynamodb-1"
+	"github.com/groupme/dynamodb-1/migrate"
+	"golang.org/x/net/context"
+)
+
+var (
+	flagApply    = flag.Bool("apply", false, "apply the planned steps")
+	flagEndpoint = flag.String("endpoint", "", "host of a DynamoDB endpoint other than the region's, e.g. localhost:8000")
+	flagRegion   = flag.String("region", "us-east-1", "region of the tables")
+	flagTimeout  = flag.Duration("timeout", time.Hour, "time allowed for the whole migration")
+	flagTLS      = flag.Bool("tls", false, "connect to -endpoint over TLS")
+)
+
+// readSpecs reads a spec file holding a Spec or a list of them.
+func readSpecs(path string) ([]*migrate.Spec, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var specs []*migrate.Spec
+	if data = bytes.TrimSpace(data); len(data) > 0 && data[0] == '{' {
+		spec := &migrate.Spec{}
+		err = json.Unmarshal(data, spec)
+		specs = append(specs, spec)
+	} else {
+		err = json.Unmarshal(data, &specs)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	for i, spec := range specs {
+		if err := spec.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: spec %d: %v", path, i, err)
+		}
+	}
+	return specs, nil
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("dynamodb-migrate: ")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: dynamodb-migrate [flags] spec.json ...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var specs []*migrate.Spec
+	for _, path := range flag.Args() {
+		s, err := readSpecs(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		specs = append(specs, s...)
+	}
+
+	endpoint := dynamodb.EndPoint(*flagRegion, *flagRegion, "dynamodb."+*flagRegion+".amazonaws.com", true)
+	if *flagEndpoint != "" {
+		endpoint = dynamodb.EndPoint(*flagEndpoint, *flagRegion, *flagEndpoint, *flagTLS)
+	}
+	auth := dynamodb.Auth(os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"))
+	client := dynamodb.Dial(endpoint, auth, nil)
+	client.ErrorLog = nil
+
+	ctx, cancel := context.WithTimeout(context.Background(), *flagTimeout)
+	defer cancel()
+	for _, spec := range specs {
+		steps, err := migrate.Plan(ctx, client, spec)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !*flagApply {
+			for _, step := range steps {
+				fmt.Println(step)
+			}
+			continue
+		}
+		err = migrate.Apply(ctx, client, steps, func(step *migrate.Step) {
+			fmt.Println(step)
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
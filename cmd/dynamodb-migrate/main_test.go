@@ -0,0 +1,47 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadSpecs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dynamodb-migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "spec.json")
+	ioutil.WriteFile(path, []byte(`{
+		"TableName": "users",
+		"KeySchema": [{"AttributeName": "id", "KeyType": "HASH"}],
+		"AttributeDefinitions": [{"AttributeName": "id", "AttributeType": "S"}, {"AttributeName": "email", "AttributeType": "S"}],
+		"ReadCapacity": 2,
+		"WriteCapacity": 1,
+		"GlobalIndexes": [{"IndexName": "ByEmail", "KeySchema": [{"AttributeName": "email", "KeyType": "HASH"}]}],
+		"TimeToLive": {"Enabled": true, "AttributeName": "expires"}
+	}`), 0644)
+	specs, err := readSpecs(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := specs[0]
+	if len(specs) != 1 || spec.TableName != "users" || spec.TimeToLive.AttributeName != "expires" {
+		t.Error("got", specs)
+	}
+	if got := spec.GlobalIndexes[0].ProvisionedThroughput; got.ReadCapacityUnits != 2 || got.WriteCapacityUnits != 1 {
+		t.Error("got", got)
+	}
+
+	ioutil.WriteFile(path, []byte(`[{"TableName": "users"}]`), 0644)
+	if _, err := readSpecs(path); err == nil {
+		t.Error("want error for a spec without a key")
+	}
+	ioutil.WriteFile(path, []byte(`{"TableName": "users", "KeySchema": [{"AttributeName": "id", "KeyType": "HASH"}]}`), 0644)
+	if _, err := readSpecs(path); err == nil {
+		t.Error("want error for a provisioned spec without capacity")
+	}
+}
@@ -0,0 +1,502 @@
+// Public Domain (-) 2012-2013 The Go DynamoDB Authors.
+// See the Go DynamoDB UNLICENSE file for details.
+
+package dynamodb
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ItemDecoder is implemented by Items which can decode their
+// attributes straight from the JSON of a response with a
+// Decoder, avoiding the maps of a ResponseItem. Items which
+// don't implement it are decoded with their Decode method or
+// by reflection.
+//
+// The dynamodb-marshal tool generates DecodeItem methods for
+// all structs.
+type ItemDecoder interface {
+	DecodeItem(dec *Decoder)
+}
+
+// Unmarshal decodes the JSON of an item, e.g.
+// {"id":{"S":"foo"}}, into v, which must be a pointer to a
+// struct as for Marshal.
+func Unmarshal(data []byte, v interface{}) error {
+	dec := NewDecoder(data)
+	dec.Decode(v)
+	return dec.Err()
+}
+
+// Decoder reads the JSON of DynamoDB responses token by token.
+// Objects are read member by member with Next and arrays
+// element by element with NextElem. Every member and element
+// must be read or skipped before moving on to the next, e.g.
+//
+//     for dec.Next() {
+//         switch string(dec.Name()) {
+//         case "id":
+//             if dec.Attr("S") {
+//                 item.ID = dec.String()
+//                 dec.End()
+//             }
+//         default:
+//             dec.Skip()
+//         }
+//     }
+//
+// Errors are sticky: once one has occurred, every method
+// returns zero values and Err reports it.
+type Decoder struct {
+	data []byte
+	pos  int
+	name []byte
+	err  error
+}
+
+// NewDecoder creates a Decoder reading data.
+func NewDecoder(data []byte) *Decoder {
+	return &Decoder{data: data}
+}
+
+// Err returns the first error encountered.
+func (d *Decoder) Err() error {
+	return d.err
+}
+
+func (d *Decoder) fail(msg string) {
+	if d.err == nil {
+		d.err = fmt.Errorf("dynamodb: %s at offset %d", msg, d.pos)
+	}
+	d.pos = len(d.data)
+}
+
+// peek skips whitespace and returns the next byte, or 0 at the
+// end of the data.
+func (d *Decoder) peek() byte {
+	for d.pos < len(d.data) {
+		switch c := d.data[d.pos]; c {
+		case ' ', '\t', '\n', '\r':
+			d.pos++
+		default:
+			return c
+		}
+	}
+	return 0
+}
+
+// Next advances to the next member of the object being read,
+// whose name is then returned by Name. It returns false once
+// the object has been read entirely, or if it is null.
+func (d *Decoder) Next() bool {
+	switch d.peek() {
+	case '{':
+		d.pos++
+		if d.peek() == '}' {
+			d.pos++
+			return false
+		}
+	case ',':
+		d.pos++
+	case '}':
+		d.pos++
+		return false
+	case 'n':
+		d.literal("null")
+		return false
+	default:
+		d.fail("expected object")
+		return false
+	}
+	d.name = d.str()
+	if d.peek() != ':' {
+		d.fail("expected colon")
+		return false
+	}
+	d.pos++
+	return d.err == nil
+}
+
+// Name returns the name of the current object member. It is
+// only valid until the next call to the Decoder.
+func (d *Decoder) Name() []byte {
+	return d.name
+}
+
+// NextElem advances to the next element of the array being
+// read. It returns false once the array has been read
+// entirely, or if it is null.
+func (d *Decoder) NextElem() bool {
+	switch d.peek() {
+	case '[':
+		d.pos++
+		if d.peek() == ']' {
+			d.pos++
+			return false
+		}
+	case ',':
+		d.pos++
+	case ']':
+		d.pos++
+		return false
+	case 'n':
+		d.literal("null")
+		return false
+	default:
+		d.fail("expected array")
+		return false
+	}
+	return d.err == nil
+}
+
+// Skip skips the current value.
+func (d *Decoder) Skip() {
+	switch d.peek() {
+	case '"':
+		d.str()
+	case '{':
+		for d.Next() {
+			d.Skip()
+		}
+	case '[':
+		for d.NextElem() {
+			d.Skip()
+		}
+	case 't':
+		d.literal("true")
+	case 'f':
+		d.literal("false")
+	case 'n':
+		d.literal("null")
+	default:
+		start := d.pos
+		for d.pos < len(d.data) {
+			c := d.data[d.pos]
+			if c != '-' && c != '+' && c != '.' && c != 'e' && c != 'E' && (c < '0' || c > '9') {
+				break
+			}
+			d.pos++
+		}
+		if d.pos == start {
+			d.fail("unexpected character")
+		}
+	}
+}
+
+// Raw skips the current value and returns its JSON.
+func (d *Decoder) Raw() []byte {
+	d.peek()
+	start := d.pos
+	d.Skip()
+	if d.err != nil {
+		return nil
+	}
+	return d.data[start:d.pos]
+}
+
+// Attr enters the attribute value being read, e.g. {"S":"foo"},
+// if it is of type typ, returning true. The value must then be
+// read, e.g. with String, and followed by a call to End.
+// Attribute values of other types are skipped.
+func (d *Decoder) Attr(typ string) bool {
+	for d.Next() {
+		if string(d.name) == typ {
+			return true
+		}
+		d.Skip()
+	}
+	return false
+}
+
+// End finishes reading an attribute value entered with Attr.
+func (d *Decoder) End() {
+	for d.Next() {
+		d.Skip()
+	}
+}
+
+// Null skips the attribute value being read if it is NULL,
+// returning true, and otherwise leaves it to be read.
+func (d *Decoder) Null() bool {
+	pos, name := d.pos, d.name
+	if d.Next() && string(d.name) == "NULL" {
+		d.Skip()
+		d.End()
+		return true
+	}
+	if d.err == nil {
+		d.pos, d.name = pos, name
+	}
+	return false
+}
+
+// String reads a string.
+func (d *Decoder) String() string {
+	return string(d.str())
+}
+
+// Bytes reads a base64 encoded string.
+func (d *Decoder) Bytes() []byte {
+	s := d.str()
+	buf := make([]byte, base64.StdEncoding.DecodedLen(len(s)))
+	n, err := base64.StdEncoding.Decode(buf, s)
+	if err != nil {
+		d.fail("invalid base64")
+		return nil
+	}
+	return buf[:n]
+}
+
+// Bool reads a number encoded as a string, true if it is 1.
+func (d *Decoder) Bool() bool {
+	s := d.str()
+	return len(s) == 1 && s[0] == '1'
+}
+
+// Int reads an integer encoded as a string.
+func (d *Decoder) Int() int64 {
+	s := d.str()
+	neg := len(s) > 0 && s[0] == '-'
+	digits := s
+	if neg {
+		digits = s[1:]
+	}
+	n, ok := parseDigits(digits)
+	if !ok || n > 1<<63 || n == 1<<63 && !neg {
+		tmp, _ := strconv.ParseInt(string(s), 10, 64)
+		return tmp
+	}
+	if neg {
+		return -int64(n)
+	}
+	return int64(n)
+}
+
+// Uint reads an unsigned integer encoded as a string.
+func (d *Decoder) Uint() uint64 {
+	s := d.str()
+	n, ok := parseDigits(s)
+	if !ok {
+		tmp, _ := strconv.ParseUint(string(s), 10, 64)
+		return tmp
+	}
+	return n
+}
+
+// parseDigits parses the decimal s without allocating, failing
+// on anything else and on overflow.
+func parseDigits(s []byte) (uint64, bool) {
+	if len(s) == 0 || len(s) > 19 {
+		return 0, false
+	}
+	var n uint64
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		n = n*10 + uint64(c-'0')
+	}
+	return n, true
+}
+
+func (d *Decoder) literal(lit string) {
+	if len(d.data)-d.pos < len(lit) || string(d.data[d.pos:d.pos+len(lit)]) != lit {
+		d.fail("invalid literal")
+		return
+	}
+	d.pos += len(lit)
+}
+
+// str reads a string, returning it without copying unless it
+// contains escapes.
+func (d *Decoder) str() []byte {
+	if d.peek() != '"' {
+		d.fail("expected string")
+		return nil
+	}
+	d.pos++
+	start := d.pos
+	for d.pos < len(d.data) {
+		switch d.data[d.pos] {
+		case '"':
+			d.pos++
+			return d.data[start : d.pos-1]
+		case '\\':
+			return d.unescape(start)
+		}
+		d.pos++
+	}
+	d.fail("unterminated string")
+	return nil
+}
+
+// unescape reads the rest of a string starting at start which
+// contains escapes.
+func (d *Decoder) unescape(start int) []byte {
+	buf := make([]byte, d.pos-start, d.pos-start+16)
+	copy(buf, d.data[start:d.pos])
+	for d.pos < len(d.data) {
+		c := d.data[d.pos]
+		switch {
+		case c == '"':
+			d.pos++
+			return buf
+		case c != '\\':
+			buf = append(buf, c)
+			d.pos++
+			continue
+		}
+		d.pos++
+		if d.pos >= len(d.data) {
+			break
+		}
+		c = d.data[d.pos]
+		d.pos++
+		switch c {
+		case '"', '\\', '/':
+			buf = append(buf, c)
+		case 'b':
+			buf = append(buf, '\b')
+		case 'f':
+			buf = append(buf, '\f')
+		case 'n':
+			buf = append(buf, '\n')
+		case 'r':
+			buf = append(buf, '\r')
+		case 't':
+			buf = append(buf, '\t')
+		case 'u':
+			r := d.hex()
+			if utf16.IsSurrogate(r) && d.pos+1 < len(d.data) && d.data[d.pos] == '\\' && d.data[d.pos+1] == 'u' {
+				d.pos += 2
+				r = utf16.DecodeRune(r, d.hex())
+			}
+			var enc [utf8.UTFMax]byte
+			buf = append(buf, enc[:utf8.EncodeRune(enc[:], r)]...)
+		default:
+			d.fail("invalid escape")
+			return nil
+		}
+	}
+	d.fail("unterminated string")
+	return nil
+}
+
+func (d *Decoder) hex() rune {
+	if len(d.data)-d.pos < 4 {
+		d.fail("invalid escape")
+		return utf8.RuneError
+	}
+	n, err := strconv.ParseUint(string(d.data[d.pos:d.pos+4]), 16, 16)
+	if err != nil {
+		d.fail("invalid escape")
+		return utf8.RuneError
+	}
+	d.pos += 4
+	return rune(n)
+}
+
+// Decode decodes the item being read into v, using its
+// DecodeItem or Decode method if it has one or reflection
+// otherwise.
+func (d *Decoder) Decode(v interface{}) {
+	if item, ok := v.(ItemDecoder); ok {
+		item.DecodeItem(d)
+		return
+	}
+	if item, ok := v.(Item); ok {
+		var data ResponseItem
+		if err := json.Unmarshal(d.Raw(), &data); err != nil && d.err == nil {
+			d.err = err
+		}
+		item.Decode(data)
+		return
+	}
+
+	fields, rv := getTypeInfo(v)
+	for d.Next() {
+		var field *fieldInfo
+		for _, f := range fields {
+			if string(d.name) == f.name {
+				field = f
+				break
+			}
+		}
+		if field == nil {
+			d.Skip()
+			continue
+		}
+		if !d.Attr(kindMap[field.kind]) {
+			continue
+		}
+		fv := rv.Field(field.index)
+		switch field.kind {
+		case binaryField:
+			fv.SetBytes(d.Bytes())
+		case boolField:
+			fv.SetBool(d.Bool())
+		case stringField:
+			fv.SetString(d.String())
+		case intField, int64Field:
+			fv.SetInt(d.Int())
+		case uintField, uint64Field:
+			fv.SetUint(d.Uint())
+		case timeField:
+			fv.Set(reflect.ValueOf(time.Unix(0, d.Int()).UTC()))
+		case ttlField:
+			fv.Set(reflect.ValueOf(DecodeTTL(d.Int())))
+		case ttlDurationField:
+			fv.SetInt(int64(DecodeTTLDuration(d.Int())))
+		case binarySetField:
+			var nv [][]byte
+			for d.NextElem() {
+				nv = append(nv, d.Bytes())
+			}
+			fv.Set(reflect.ValueOf(nv))
+		case boolSetField:
+			var nv []bool
+			for d.NextElem() {
+				nv = append(nv, d.Bool())
+			}
+			fv.Set(reflect.ValueOf(nv))
+		case stringSetField:
+			var nv []string
+			for d.NextElem() {
+				nv = append(nv, d.String())
+			}
+			fv.Set(reflect.ValueOf(nv))
+		case intSetField:
+			var nv []int
+			for d.NextElem() {
+				nv = append(nv, int(d.Int()))
+			}
+			fv.Set(reflect.ValueOf(nv))
+		case int64SetField:
+			var nv []int64
+			for d.NextElem() {
+				nv = append(nv, d.Int())
+			}
+			fv.Set(reflect.ValueOf(nv))
+		case uintSetField:
+			var nv []uint
+			for d.NextElem() {
+				nv = append(nv, uint(d.Uint()))
+			}
+			fv.Set(reflect.ValueOf(nv))
+		case uint64SetField:
+			var nv []uint64
+			for d.NextElem() {
+				nv = append(nv, d.Uint())
+			}
+			fv.Set(reflect.ValueOf(nv))
+		}
+		d.End()
+	}
+}
@@ -0,0 +1,118 @@
+package dynamodb
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type decoderItem struct {
+	Name    string `ddb:"name,HASH"`
+	Data    []byte
+	OK      bool
+	Count   int
+	Big     int64
+	Size    uint
+	Total   uint64
+	Created time.Time
+	Blobs   [][]byte
+	Flags   []bool
+	Tags    []string
+	Ints    []int
+	Int64s  []int64
+	Uints   []uint
+	Uint64s []uint64
+}
+
+func TestUnmarshal(t *testing.T) {
+	in := &decoderItem{
+		Name:    "quotes \" \\ and   \U0001F600 <tags>\n",
+		Data:    []byte{0, 1, 2},
+		OK:      true,
+		Count:   -3,
+		Big:     -1 << 63,
+		Size:    7,
+		Total:   1<<64 - 1,
+		Created: time.Unix(0, 1234567890).UTC(),
+		Blobs:   [][]byte{{1}, {2, 3}},
+		Flags:   []bool{true, false},
+		Tags:    []string{"a", "b"},
+		Ints:    []int{1, -1},
+		Int64s:  []int64{1 << 40},
+		Uints:   []uint{3},
+		Uint64s: []uint64{4},
+	}
+	data, _ := Marshal(in)
+	// unknown attributes of any shape are skipped
+	data = append([]byte(`{"unknown":{"M":{"a":{"L":[{"N":"1"},{"NULL":true}]}}},`), data[1:]...)
+
+	out := &decoderItem{}
+	if err := Unmarshal(data, out); err != nil {
+		t.Fatal(err)
+	}
+	var item ResponseItem
+	json.Unmarshal(data, &item)
+	mapped := &decoderItem{}
+	decode(mapped, item)
+	if !reflect.DeepEqual(in, out) || !reflect.DeepEqual(mapped, out) {
+		t.Error("want", in)
+		t.Error("got ", out)
+	}
+
+	// escapes are decoded like encoding/json does
+	var s string
+	json.Unmarshal([]byte(`"a\"\\\/\b\f\n\r\té😀"`), &s)
+	out = &decoderItem{}
+	Unmarshal([]byte(`{"name":{"S":"a\"\\\/\b\f\n\r\té😀"}}`), out)
+	if out.Name != s {
+		t.Error("want", s)
+		t.Error("got ", out.Name)
+	}
+
+	// attributes of another type are ignored
+	out = &decoderItem{Count: 1}
+	if err := Unmarshal([]byte(`{"Count":{"S":"2"},"OK":{"N":"1"}}`), out); err != nil || out.Count != 1 || !out.OK {
+		t.Error("got", out, err)
+	}
+
+	for _, bad := range []string{`{"name":{"S":"a}}`, `{"name" {"S":"a"}}`, `{"Count":{"N":1}}`, `[]`} {
+		if err := Unmarshal([]byte(bad), &decoderItem{}); err == nil {
+			t.Error("want error for", bad)
+		}
+	}
+}
+
+func TestDecoderNull(t *testing.T) {
+	dec := NewDecoder([]byte(`[{"NULL":true},{"S":"a"}]`))
+	var got []interface{}
+	for dec.NextElem() {
+		if dec.Null() {
+			got = append(got, nil)
+		} else if dec.Attr("S") {
+			got = append(got, dec.String())
+			dec.End()
+		}
+	}
+	if dec.Err() != nil || len(got) != 2 || got[0] != nil || got[1] != "a" {
+		t.Error("got", got, dec.Err())
+	}
+}
+
+func BenchmarkDecodeResponseItem(b *testing.B) {
+	data, _ := Marshal(&decoderItem{Name: "name", Tags: []string{"a", "b", "c"}, Ints: []int{1, 2, 3}})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var item ResponseItem
+		json.Unmarshal(data, &item)
+		decode(&decoderItem{}, item)
+	}
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	data, _ := Marshal(&decoderItem{Name: "name", Tags: []string{"a", "b", "c"}, Ints: []int{1, 2, 3}})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Unmarshal(data, &decoderItem{})
+	}
+}
@@ -82,7 +82,6 @@ import (
 	"bytes"
 	"crypto/hmac"
 	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -90,7 +89,10 @@ import (
 	"log"
 	"math"
 	"net/http"
+	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/net/context"
@@ -151,6 +153,17 @@ var (
 	USWest2      = EndPoint("Northern California", "us-west-2", "dynamodb.us-west-2.amazonaws.com", true)
 )
 
+// StreamsEndPoint returns the endpoint of DynamoDB Streams in
+// the region of the DynamoDB endpoint e, for use with
+// DialStreams. Endpoints other than Amazon's, e.g. a local
+// DynamoDB, serve streams on the same host.
+func StreamsEndPoint(e endpoint) endpoint {
+	if strings.HasPrefix(e.host, "dynamodb.") {
+		return EndPoint(e.name, e.region, "streams."+e.host, e.tls)
+	}
+	return e
+}
+
 type auth struct {
 	accessKey string
 	secretKey []byte
@@ -200,15 +213,42 @@ func (e Error) Info() (errtype string, message string) {
 	return errtype, info["message"]
 }
 
+// Type returns the error type DynamoDB responded with, or a
+// placeholder naming the HTTP status code if it is unknown.
+func (e Error) Type() string {
+	errtype, _ := e.Info()
+	if errtype == "" {
+		return fmt.Sprintf("HTTP%d", e.StatusCode)
+	}
+	return errtype
+}
+
 // Retry returns error is safe to retry
 func (e Error) Retry() bool {
+	if e.Throttled() {
+		return true
+	}
 	errtype, _ := e.Info()
 	switch errtype {
 	case "InternalServerError":
 		return true
+	case "ServiceUnavailableException":
+		return true
+	default:
+		return false
+	}
+}
+
+// Throttled returns whether the request was rejected for
+// exceeding the provisioned throughput or request rate.
+func (e Error) Throttled() bool {
+	errtype, _ := e.Info()
+	switch errtype {
 	case "ProvisionedThroughputExceededException":
 		return true
-	case "ServiceUnavailableException":
+	case "RequestLimitExceeded":
+		return true
+	case "ThrottlingException":
 		return true
 	default:
 		return false
@@ -236,9 +276,16 @@ func (e Error) Retry() bool {
 //
 //    $ dynamodb-marshal model.go
 //
+// Or mark the structs with a //dynamodb:generate comment and
+// let go generate run the tool on the whole package:
+//
+//     //go:generate dynamodb-marshal
+//
 // This will generate a model_marshal.go file which would
 // contain implementations for the Encode() and Decode()
-// methods that satisfy the Item interface, e.g.
+// methods that satisfy the Item interface, as well as the
+// EncodeKey() and EncodeExpected() methods of the KeyEncoder
+// and ExpectedEncoder interfaces, e.g.
 //
 //     package campaign
 //
@@ -255,7 +302,29 @@ func (e Error) Retry() bool {
 // default implementation.
 type Item interface {
 	Encode(buf *bytes.Buffer)
-	Decode(data ResponseItem)
+	Decode(data map[string]map[string]interface{})
+}
+
+// KeyEncoder is implemented by Items which can encode just
+// their HASH and RANGE key attributes, as sent by Get and
+// Delete. Items which don't implement it have their key
+// encoded by reflection.
+//
+// The dynamodb-marshal tool generates EncodeKey methods for
+// structs with tagged key attributes.
+type KeyEncoder interface {
+	EncodeKey(buf *bytes.Buffer)
+}
+
+// ExpectedEncoder is implemented by Items which can encode
+// their attributes as the Expected values of a conditional
+// write, as sent by PutIf. Items which don't implement it are
+// encoded by reflection.
+//
+// The dynamodb-marshal tool generates EncodeExpected methods
+// for all structs.
+type ExpectedEncoder interface {
+	EncodeExpected(buf *bytes.Buffer)
 }
 
 type Key struct {
@@ -265,6 +334,17 @@ type Key struct {
 // in all DynamoDB API calls.
 type Map map[string]interface{}
 
+// Comparison operators for Query.Where.
+const (
+	EQ         = "EQ"
+	LE         = "LE"
+	LT         = "LT"
+	GE         = "GE"
+	GT         = "GT"
+	BeginsWith = "BEGINS_WITH"
+	Between    = "BETWEEN"
+)
+
 type Query struct {
 	table      *Table
 	cursor     Key
@@ -273,6 +353,34 @@ type Query struct {
 	index      string
 	limit      int
 	selector   string
+	attrs      []string
+	conditions []condition
+	match      interface{}
+}
+
+type condition struct {
+	name   string
+	op     string
+	values [][]byte
+}
+
+// Where adds a condition on the key attribute name, comparing
+// it to values with op. The values are attribute values encoded
+// as JSON, e.g. {"S":"foo"}. Every query needs an EQ condition
+// on the hash key.
+func (q *Query) Where(name, op string, values ...[]byte) *Query {
+	q.conditions = append(q.conditions, condition{name, op, values})
+	return q
+}
+
+// Match adds the EQ condition on the hash key of the queried
+// index, or of the table, taking the value from item by
+// attribute name. The key schema is the one loaded with
+// Table.Describe or, until then, the one declared by the tags
+// of item.
+func (q *Query) Match(item interface{}) *Query {
+	q.match = item
+	return q
 }
 
 func (q *Query) Sort(order byte) *Query {
@@ -289,12 +397,18 @@ func (q *Query) Sort(order byte) *Query {
 // 	return q
 // }
 
+// Index queries the secondary index called name. Once the
+// schema of the table is loaded with Table.Describe, or if the
+// item passed to Match has tags, a query on an index unknown to
+// them fails without being sent. Otherwise the query is sent as
+// is and DynamoDB reports an unknown index.
 func (q *Query) Index(name string) *Query {
 	q.index = name
 	return q
 }
 
 func (q *Query) Only(attrs ...string) *Query {
+	q.attrs = attrs
 	return q
 }
 
@@ -303,6 +417,133 @@ func (q *Query) Limit(n int) *Query {
 	return q
 }
 
+// Items runs the query and returns the items found, following
+// LastEvaluatedKey across pages until Limit items are found or
+// there are no more.
+func (q *Query) Items(ctx context.Context, consistent bool) ([]ResponseItem, error) {
+	var items []ResponseItem
+	err := q.Decode(ctx, consistent, func(dec *Decoder) {
+		var item ResponseItem
+		if err := json.Unmarshal(dec.Raw(), &item); err != nil {
+			dec.fail(err.Error())
+		}
+		items = append(items, item)
+	})
+	return items, err
+}
+
+// Decode runs the query like Items but calls fn to decode each
+// item straight from the response, e.g. with dec.Decode or the
+// DecodeItem method of an ItemDecoder. fn must read the whole
+// item.
+func (q *Query) Decode(ctx context.Context, consistent bool, fn func(dec *Decoder)) error {
+	if q.match != nil {
+		cond, err := q.table.hashCondition(q.index, q.match)
+		if err != nil {
+			return err
+		}
+		query := *q
+		query.match = nil
+		query.conditions = append([]condition{cond}, q.conditions...)
+		return query.Decode(ctx, consistent, fn)
+	}
+	if q.index != "" {
+		if _, err := q.table.indexSchema(q.index, nil); err != nil {
+			return err
+		}
+	}
+
+	found := 0
+	var start []byte
+	for {
+		payload := q.table.begin()
+		q.payload(payload, consistent, start, found)
+		resp, err := q.table.client.call(ctx, "Query", q.table.names, payload.Bytes())
+		putBuffer(payload)
+		if err != nil {
+			return err
+		}
+		start = nil
+		dec := NewDecoder(resp)
+		for dec.Next() {
+			switch string(dec.Name()) {
+			case "Items":
+				for dec.NextElem() {
+					fn(dec)
+					found++
+				}
+			case "LastEvaluatedKey":
+				if dec.peek() == '{' {
+					start = dec.Raw()
+				} else {
+					dec.Skip()
+				}
+			default:
+				dec.Skip()
+			}
+		}
+		if dec.Err() != nil {
+			return dec.Err()
+		}
+		if start == nil || q.limit > 0 && found >= q.limit {
+			return nil
+		}
+	}
+}
+
+// payload finishes the request begun in payload for the page
+// starting after the key start, found items into the query.
+func (q *Query) payload(payload *bytes.Buffer, consistent bool, start []byte, found int) {
+	if consistent {
+		payload.WriteString(`, "ConsistentRead":true`)
+	} else {
+		payload.WriteString(`, "ConsistentRead":false`)
+	}
+	payload.WriteString(`, "KeyConditions":{`)
+	for i, cond := range q.conditions {
+		if i > 0 {
+			payload.WriteString(", ")
+		}
+		writeString(payload, cond.name)
+		payload.WriteString(`:{"ComparisonOperator":`)
+		writeString(payload, cond.op)
+		payload.WriteString(`, "AttributeValueList":[`)
+		for j, value := range cond.values {
+			if j > 0 {
+				payload.WriteByte(',')
+			}
+			payload.Write(value)
+		}
+		payload.WriteString("]}")
+	}
+	payload.WriteByte('}')
+	if q.index != "" {
+		payload.WriteString(`, "IndexName":`)
+		writeString(payload, q.index)
+	}
+	if q.limit > 0 {
+		payload.WriteString(`, "Limit":`)
+		payload.WriteString(strconv.Itoa(q.limit - found))
+	}
+	if q.descending {
+		payload.WriteString(`, "ScanIndexForward":false`)
+	}
+	if q.selector != "" {
+		payload.WriteString(`, "Select":`)
+		writeString(payload, q.selector)
+	}
+	if len(q.attrs) > 0 {
+		attrs, _ := json.Marshal(q.attrs)
+		payload.WriteString(`, "AttributesToGet":`)
+		payload.Write(attrs)
+	}
+	if start != nil {
+		payload.WriteString(`, "ExclusiveStartKey":`)
+		payload.Write(start)
+	}
+	payload.WriteByte('}')
+}
+
 func (q *Query) Run(consistent bool) error {
 	// q.table.client.RawRequest("Query", payload)
 	return nil
@@ -328,26 +569,111 @@ func (s *Session) Get(item interface{}, consistent bool) error {
 	return s.table.Get(s.ctx, item, consistent)
 }
 
+func (s *Session) GetWith(item interface{}, consistent bool, opts *ItemOptions) (*ItemResult, error) {
+	return s.table.GetWith(s.ctx, item, consistent, opts)
+}
+
 func (s *Session) Delete(item interface{}) error {
 	return s.table.Delete(s.ctx, item)
 }
 
+func (s *Session) DeleteWith(item interface{}, opts *ItemOptions) (*ItemResult, error) {
+	return s.table.DeleteWith(s.ctx, item, opts)
+}
+
 func (s *Session) Put(item interface{}) error {
 	return s.table.Put(s.ctx, item)
 }
 
+func (s *Session) PutWith(item interface{}, opts *ItemOptions) (*ItemResult, error) {
+	return s.table.PutWith(s.ctx, item, opts)
+}
+
 func (s *Session) PutIf(newItem, oldItem interface{}) error {
 	return s.table.PutIf(s.ctx, newItem, oldItem)
 }
 
+func (s *Session) PutIfWith(newItem, oldItem interface{}, opts *ItemOptions) (*ItemResult, error) {
+	return s.table.PutIfWith(s.ctx, newItem, oldItem, opts)
+}
+
 func (s *Session) Add(item interface{}) error {
 	return s.table.Add(s.ctx, item)
 }
 
+func (s *Session) AddWith(item interface{}, opts *ItemOptions) (*ItemResult, error) {
+	return s.table.AddWith(s.ctx, item, opts)
+}
+
+// Values for ItemOptions.
+const (
+	ReturnIndexes = "INDEXES"
+	ReturnNone    = "NONE"
+	ReturnSize    = "SIZE"
+	ReturnTotal   = "TOTAL"
+)
+
+// ItemOptions asks DynamoDB to report what an item operation
+// cost in its ItemResult.
+type ItemOptions struct {
+	// ReturnConsumedCapacity is one of ReturnTotal,
+	// ReturnIndexes or ReturnNone.
+	ReturnConsumedCapacity string
+
+	// ReturnItemCollectionMetrics is one of ReturnSize or
+	// ReturnNone. It only applies to writes on tables with
+	// local secondary indexes and is ignored by Get.
+	ReturnItemCollectionMetrics string
+}
+
+// write appends the options to a JSON object being written
+func (o *ItemOptions) write(buf *bytes.Buffer, write bool) {
+	if o == nil {
+		return
+	}
+	if o.ReturnConsumedCapacity != "" {
+		buf.WriteString(`, "ReturnConsumedCapacity":`)
+		writeString(buf, o.ReturnConsumedCapacity)
+	}
+	if write && o.ReturnItemCollectionMetrics != "" {
+		buf.WriteString(`, "ReturnItemCollectionMetrics":`)
+		writeString(buf, o.ReturnItemCollectionMetrics)
+	}
+}
+
 // Table operates on a named DynamoDB table
 type Table struct {
 	client *Client
 	name   string
+	names  []string
+
+	// prefix opens the payload of every request on the table
+	// with its escaped name
+	prefix string
+
+	mu     sync.RWMutex
+	schema *tableSchema
+}
+
+func newTable(c *Client, name string) *Table {
+	buf := &bytes.Buffer{}
+	buf.WriteString(`{"TableName":`)
+	writeString(buf, name)
+	return &Table{
+		client: c,
+		name:   name,
+		names:  []string{name},
+		prefix: buf.String(),
+	}
+}
+
+// begin starts the payload of a request on the table in a
+// pooled buffer, which the caller puts back once the call has
+// returned.
+func (t *Table) begin() *bytes.Buffer {
+	payload := getBuffer()
+	payload.WriteString(t.prefix)
+	return payload
 }
 
 // Session creates a new Session for Context and Table
@@ -361,102 +687,183 @@ func (t *Table) Get(
 	item interface{},
 	consistent bool,
 ) error {
-	payload := &bytes.Buffer{}
-	encodedKey := bytes.Buffer{}
-	encode(item, &encodedKey, true, false)
-	fmt.Fprintf(
-		payload,
-		`{"TableName":"%s", "Key":%s, "ConsistentRead":%t}`,
-		t.name,
-		encodedKey.String(),
-		consistent,
-	)
-	resp, err := t.client.CallBytes(ctx, "GetItem", payload.Bytes())
+	_, err := t.GetWith(ctx, item, consistent, nil)
+	return err
+}
+
+// GetWith fetches and populates the item, reporting what was
+// requested by opts.
+func (t *Table) GetWith(
+	ctx context.Context,
+	item interface{},
+	consistent bool,
+	opts *ItemOptions,
+) (*ItemResult, error) {
+	payload := t.begin()
+	defer putBuffer(payload)
+	payload.WriteString(`, "Key":`)
+	if err := t.encodeKey(item, payload); err != nil {
+		return nil, err
+	}
+	if consistent {
+		payload.WriteString(`, "ConsistentRead":true`)
+	} else {
+		payload.WriteString(`, "ConsistentRead":false`)
+	}
+	opts.write(payload, false)
+	payload.WriteByte('}')
+	resp, err := t.client.call(ctx, "GetItem", t.names, payload.Bytes())
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	// the item is decoded straight from the response
+	result := &ItemResult{}
+	found := false
+	dec := NewDecoder(resp)
+	for dec.Next() {
+		switch string(dec.Name()) {
+		case "Item":
+			found = dec.peek() == '{'
+			dec.Decode(item)
+		case "ConsumedCapacity":
+			err = json.Unmarshal(dec.Raw(), &result.ConsumedCapacity)
+		default:
+			dec.Skip()
+		}
 	}
-	var getData GetItem
-	err = json.Unmarshal(resp, &getData)
-	if getData.Item == nil {
-		return errors.New("Item does not exist")
+	if dec.Err() != nil {
+		return result, dec.Err()
 	}
-	decode(item, getData.Item)
-	return err
+	if !found {
+		return result, errors.New("Item does not exist")
+	}
+	return result, err
 }
 
 func (t *Table) Delete(ctx context.Context, item interface{}) error {
-	payload := &bytes.Buffer{}
-	encodedKey := bytes.Buffer{}
-	encode(item, &encodedKey, true, false)
-	fmt.Fprintf(
-		payload,
-		`{"TableName":"%s", "Key":%s}`,
-		t.name,
-		encodedKey.String(),
-	)
-	_, err := t.client.CallBytes(ctx, "DeleteItem", payload.Bytes())
+	_, err := t.DeleteWith(ctx, item, nil)
 	return err
 }
 
+// DeleteWith deletes item, reporting what was requested by
+// opts.
+func (t *Table) DeleteWith(ctx context.Context, item interface{}, opts *ItemOptions) (*ItemResult, error) {
+	payload := t.begin()
+	defer putBuffer(payload)
+	payload.WriteString(`, "Key":`)
+	if err := t.encodeKey(item, payload); err != nil {
+		return nil, err
+	}
+	opts.write(payload, true)
+	payload.WriteByte('}')
+	return t.write(ctx, "DeleteItem", payload.Bytes(), opts)
+}
+
 // Put puts item
 func (t *Table) Put(ctx context.Context, item interface{}) error {
-	payload := &bytes.Buffer{}
-	encodedItem := bytes.Buffer{}
-	encode(item, &encodedItem, false, false)
-	fmt.Fprintf(
-		payload,
-		`{"TableName":"%s", "Item":%s}`,
-		t.name,
-		encodedItem.String(),
-	)
-	_, err := t.client.CallBytes(ctx, "PutItem", payload.Bytes())
+	_, err := t.PutWith(ctx, item, nil)
 	return err
 }
 
+// PutWith puts item, reporting what was requested by opts.
+func (t *Table) PutWith(ctx context.Context, item interface{}, opts *ItemOptions) (*ItemResult, error) {
+	payload := t.begin()
+	defer putBuffer(payload)
+	payload.WriteString(`, "Item":`)
+	start := payload.Len()
+	encode(item, payload, false, false)
+	if err := t.checkKey(payload.Bytes()[start:]); err != nil {
+		return nil, err
+	}
+	opts.write(payload, true)
+	payload.WriteByte('}')
+	return t.write(ctx, "PutItem", payload.Bytes(), opts)
+}
+
 // PutIf only puts if item hasn't changed
 func (t *Table) PutIf(ctx context.Context, newItem, oldItem interface{}) error {
-	payload := &bytes.Buffer{}
-	encodedNewItem := bytes.Buffer{}
-	encodedOldItem := bytes.Buffer{}
-	encode(newItem, &encodedNewItem, false, false)
-	encode(oldItem, &encodedOldItem, false, true)
-	fmt.Fprintf(
-		payload,
-		`{"TableName":"%s", "Item":%s, "Expected":%s}`,
-		t.name,
-		encodedNewItem.String(),
-		encodedOldItem.String(),
-	)
-	_, err := t.client.CallBytes(ctx, "PutItem", payload.Bytes())
+	_, err := t.PutIfWith(ctx, newItem, oldItem, nil)
 	return err
 }
 
+// PutIfWith only puts if item hasn't changed, reporting what
+// was requested by opts.
+func (t *Table) PutIfWith(ctx context.Context, newItem, oldItem interface{}, opts *ItemOptions) (*ItemResult, error) {
+	payload := t.begin()
+	defer putBuffer(payload)
+	payload.WriteString(`, "Item":`)
+	start := payload.Len()
+	encode(newItem, payload, false, false)
+	if err := t.checkKey(payload.Bytes()[start:]); err != nil {
+		return nil, err
+	}
+	payload.WriteString(`, "Expected":`)
+	encode(oldItem, payload, false, true)
+	opts.write(payload, true)
+	payload.WriteByte('}')
+	return t.write(ctx, "PutItem", payload.Bytes(), opts)
+}
+
 // Add puts item if the key doesn't already exist
 func (t *Table) Add(ctx context.Context, item interface{}) error {
-	payload := &bytes.Buffer{}
-	encodedItem := bytes.Buffer{}
-	encode(item, &encodedItem, false, false)
+	_, err := t.AddWith(ctx, item, nil)
+	return err
+}
+
+// AddWith puts item if the key doesn't already exist,
+// reporting what was requested by opts.
+func (t *Table) AddWith(ctx context.Context, item interface{}, opts *ItemOptions) (*ItemResult, error) {
+	payload := t.begin()
+	defer putBuffer(payload)
+	payload.WriteString(`, "Item":`)
+	start := payload.Len()
+	encode(item, payload, false, false)
+	if err := t.checkKey(payload.Bytes()[start:]); err != nil {
+		return nil, err
+	}
+	payload.WriteString(`, "Expected":{`)
 	fields, _ := getTypeInfo(item)
-	var keyStrings []string
+	first := true
 	for _, field := range fields {
-		if field.keyType != "" {
-			keyStrings = append(keyStrings, fmt.Sprintf(`"%s": {"Exists":false}`, field.name))
+		if field.keyType == "" {
+			continue
+		}
+		if !first {
+			payload.WriteString(", ")
 		}
+		first = false
+		writeString(payload, field.name)
+		payload.WriteString(`: {"Exists":false}`)
 	}
-	fmt.Fprintf(
-		payload,
-		`{"TableName":"%s", "Item":%s, "Expected":{%s}}`,
-		t.name,
-		encodedItem.String(),
-		strings.Join(keyStrings, ", "),
-	)
-	_, err := t.client.CallBytes(ctx, "PutItem", payload.Bytes())
-	return err
+	payload.WriteByte('}')
+	opts.write(payload, true)
+	payload.WriteByte('}')
+	return t.write(ctx, "PutItem", payload.Bytes(), opts)
 }
 
-// TODO implement me
+// write sends a write operation and parses the ItemResult if
+// opts asked for one
+func (t *Table) write(
+	ctx context.Context,
+	method string,
+	payload []byte,
+	opts *ItemOptions,
+) (*ItemResult, error) {
+	resp, err := t.client.call(ctx, method, t.names, payload)
+	if err != nil {
+		return nil, err
+	}
+	result := &ItemResult{}
+	if opts != nil {
+		err = json.Unmarshal(resp, result)
+	}
+	return result, err
+}
+
+// Query creates a Query on the table.
 func (t *Table) Query() *Query {
-	return &Query{}
+	return &Query{table: t}
 }
 
 // TODO implement me
@@ -478,13 +885,24 @@ func Dial(region endpoint, creds auth, transport http.RoundTripper) *Client {
 	}
 	return &Client{
 		Retry:     RetryDefault,
+		ErrorLog:  log.New(os.Stderr, "", log.LstdFlags),
 		auth:      creds,
 		endpoint:  region,
+		target:    "DynamoDB_20120810.",
 		web:       &http.Client{Transport: transport},
 		transport: transport,
 	}
 }
 
+// DialStreams creates a new Client calling the DynamoDB Streams
+// API, e.g. ListStreams and GetRecords, at the endpoint
+// returned by StreamsEndPoint. See the streams package.
+func DialStreams(region endpoint, creds auth, transport http.RoundTripper) *Client {
+	c := Dial(region, creds, transport)
+	c.target = "DynamoDBStreams_20120810."
+	return c
+}
+
 // Client communicates over HTTP
 type Client struct {
 	// Retry defines retry behavior.
@@ -493,26 +911,46 @@ type Client struct {
 	// If -1, Client retries forever.
 	Retry int
 
-	auth      auth
-	endpoint  endpoint
-	web       *http.Client
-	transport http.RoundTripper
-}
-
-// memoize tables
-var tables map[string]*Table
-
-// Table gets or initializes *Table
-func (c *Client) Table(name string) *Table {
-	if tables[name] != nil {
-		return tables[name]
-	}
-	return &Table{
-		client: c,
-		name:   name,
-	}
+	// Limiter, if set, limits the rate of requests sent to
+	// each table and adapts it to throttling.
+	Limiter *RateLimiter
+
+	// Metrics, if set, observes every call made by the Client.
+	// The capacity consumed by item operations is requested
+	// automatically so that it can be reported.
+	Metrics MetricsCollector
+
+	// Tracer, if set, starts a Span for every call made by the
+	// Client. The context passed on to middleware carries the
+	// Span.
+	Tracer Tracer
+
+	// ErrorLog logs the body of every response with an HTTP
+	// status code other than 200, but for the missing tables
+	// the waiters poll for. Dial sets it to log to standard
+	// error; set it to nil to disable logging.
+	ErrorLog *log.Logger
+
+	auth       auth
+	endpoint   endpoint
+	target     string
+	web        *http.Client
+	transport  http.RoundTripper
+	middleware []Middleware
+	handler    Handler
+	registry   registry
+
+	// key caches the signing key for keyDate
+	keyMu   sync.Mutex
+	keyDate string
+	key     []byte
 }
 
+// CreateTable creates the table called name with provisioned
+// throughput, deriving its key from the HASH and RANGE tags of
+// schemaItem. It returns once DynamoDB accepts the table,
+// without waiting for it to become active, see WaitUntilActive.
+// See CreateTableWith for more options.
 func (c *Client) CreateTable(
 	ctx context.Context,
 	name string,
@@ -522,50 +960,333 @@ func (c *Client) CreateTable(
 	globalIndexes []GlobalIndex,
 	localIndexes []Index,
 ) (*TableDesc, error) {
-	var keys []KeyItem
-	var attrDefs []AttributeDefinition
+	return c.CreateTableWith(ctx, name, schemaItem, &TableOptions{
+		ReadCapacity:  readCapacity,
+		WriteCapacity: writeCapacity,
+		GlobalIndexes: globalIndexes,
+		LocalIndexes:  localIndexes,
+	})
+}
+
+// Billing modes of TableOptions.
+const (
+	BillingProvisioned   = "PROVISIONED"
+	BillingPayPerRequest = "PAY_PER_REQUEST"
+)
+
+// Table classes of TableOptions.
+const (
+	TableClassStandard                 = "STANDARD"
+	TableClassStandardInfrequentAccess = "STANDARD_INFREQUENT_ACCESS"
+)
+
+// TableOptions describes a table to CreateTableWith.
+type TableOptions struct {
+	// BillingMode is BillingProvisioned, the default, or
+	// BillingPayPerRequest.
+	BillingMode string
+
+	// ReadCapacity and WriteCapacity are the provisioned
+	// throughput of the table, and of its global indexes which
+	// don't specify their own. They are ignored when billing
+	// per request.
+	ReadCapacity  int
+	WriteCapacity int
+
+	// GlobalIndexes and LocalIndexes are the secondary indexes
+	// of the table. Their key attributes are defined from the
+	// fields of the schema item of the same name.
+	GlobalIndexes []GlobalIndex
+	LocalIndexes  []Index
+
+	// Stream, if set, enables a stream on the table.
+	Stream *StreamSpecification
+
+	// SSE, if set, configures encryption at rest.
+	SSE *SSESpecification
+
+	// Tags are applied to the table as it is created, e.g.
+	// for cost allocation. See TagResource to tag it later.
+	Tags []Tag
+
+	// TableClass is one of the TableClass constants, or empty
+	// for the default.
+	TableClass string
+
+	// TimeToLiveAttribute is the attribute holding the
+	// expiry time of items. It defaults to the field of the
+	// schema item tagged with ttl.
+	TimeToLiveAttribute string
+
+	// EnableTimeToLive enables time to live on the
+	// TimeToLiveAttribute once the table is created. As
+	// DynamoDB only allows it on active tables, creating the
+	// table then blocks until it is active, which may take
+	// minutes. See UpdateTimeToLive to enable it later.
+	EnableTimeToLive bool
+}
+
+// CreateTableWith creates the table called name as described by
+// opts, deriving its key from the HASH and RANGE tags of
+// schemaItem and the types of all key attributes, including
+// those of indexes, from its fields.
+//
+// Secondary indexes which aren't in opts are derived from the
+// tags of schemaItem, with options naming the index and the
+// key type of the field in it, and optionally the projection
+// of the index, e.g.
+//
+//     type User struct {
+//         ID     string    `ddb:"id,HASH"`
+//         Email  string    `ddb:"email,gsi=ByEmail:HASH:KEYS_ONLY"`
+//         Team   string    `ddb:"team,gsi=ByTeam:HASH"`
+//         Joined time.Time `ddb:"joined,gsi=ByTeam:RANGE,lsi=ByJoined:RANGE"`
+//         Name   string    `ddb:"name,project=ByJoined"`
+//     }
+//
+// Global indexes are declared with gsi and need a HASH key.
+// Local indexes are declared with lsi on their RANGE key and
+// share the HASH key of the table. Indexes project ALL
+// attributes by default, or INCLUDE those of the fields marked
+// with project.
+//
+// A time.Time or time.Duration field tagged with ttl, e.g.
+//
+//     Expires time.Time `ddb:"expires,ttl"`
+//
+// is stored as the epoch seconds at which the item expires.
+// Time to live is only enabled on it if opts set
+// EnableTimeToLive, in which case CreateTableWith blocks until
+// the table is active or ctx is done. Otherwise it returns once
+// DynamoDB accepts the table.
+func (c *Client) CreateTableWith(
+	ctx context.Context,
+	name string,
+	schemaItem interface{},
+	opts *TableOptions,
+) (*TableDesc, error) {
+	def, err := DefineTable(name, schemaItem, opts)
+	if err != nil {
+		return nil, err
+	}
+	return c.CreateTableFrom(ctx, def)
+}
+
+// TableDefinition is the complete definition of a table, as
+// sent by CreateTableFrom. Its options include the secondary
+// indexes declared by tags, and every key attribute of the
+// table and its indexes is defined.
+type TableDefinition struct {
+	TableName            string
+	KeySchema            []KeyItem
+	AttributeDefinitions []AttributeDefinition
+	TableOptions
+}
+
+// DefineTable defines the table called name like
+// CreateTableWith does.
+func DefineTable(name string, schemaItem interface{}, opts *TableOptions) (*TableDefinition, error) {
+	def := &TableDefinition{TableName: name}
+	if opts != nil {
+		def.TableOptions = *opts
+	}
+	globals, locals, err := modelIndexes(schemaItem)
+	if err != nil {
+		return nil, err
+	}
+	def.GlobalIndexes = append([]GlobalIndex(nil), def.GlobalIndexes...)
+	for _, index := range globals {
+		if !hasIndex(opts, index.IndexName) {
+			def.GlobalIndexes = append(def.GlobalIndexes, index)
+		}
+	}
+	def.LocalIndexes = append([]Index(nil), def.LocalIndexes...)
+	for _, index := range locals {
+		if !hasIndex(opts, index.IndexName) {
+			def.LocalIndexes = append(def.LocalIndexes, index)
+		}
+	}
 	fields, _ := getTypeInfo(schemaItem)
 	for _, field := range fields {
 		if field.keyType != "" {
-			keys = append(
-				keys,
-				KeyItem{
-					AttributeName: field.name,
-					KeyType:       field.keyType,
-				},
-			)
-
-			attrDefs = append(
-				attrDefs,
-				AttributeDefinition{
-					AttributeName: field.name,
-					AttributeType: kindMap[field.kind],
-				},
-			)
-		}
-	}
-
-	payload, err := c.Call(
-		ctx,
-		"CreateTable",
-		TableCreate{
-			attrDefs,
-			keys,
-			globalIndexes,
-			localIndexes,
-			ProvisionedThroughput{readCapacity, writeCapacity},
-			name,
-		},
-	)
+			def.KeySchema = append(def.KeySchema, KeyItem{AttributeName: field.name, KeyType: field.keyType})
+		}
+		if (field.kind == ttlField || field.kind == ttlDurationField) && def.TimeToLiveAttribute == "" {
+			def.TimeToLiveAttribute = field.name
+		}
+	}
+
+	// every key attribute is defined once
+	define := func(keys []KeyItem) error {
+	next:
+		for _, key := range keys {
+			for _, attr := range def.AttributeDefinitions {
+				if attr.AttributeName == key.AttributeName {
+					continue next
+				}
+			}
+			var attrType string
+			for _, field := range fields {
+				if field.name == key.AttributeName {
+					attrType = kindMap[field.kind]
+				}
+			}
+			if len(attrType) != 1 {
+				return fmt.Errorf("dynamodb: key attribute %s has no scalar field in %T", key.AttributeName, schemaItem)
+			}
+			def.AttributeDefinitions = append(def.AttributeDefinitions, AttributeDefinition{key.AttributeName, attrType})
+		}
+		return nil
+	}
+	if err := define(def.KeySchema); err != nil {
+		return nil, err
+	}
+	for i := range def.GlobalIndexes {
+		index := &def.GlobalIndexes[i]
+		if err := define(index.KeySchema); err != nil {
+			return nil, err
+		}
+	}
+	def.defaultIndexes()
+	for _, index := range def.LocalIndexes {
+		if err := define(index.KeySchema); err != nil {
+			return nil, err
+		}
+	}
+	return def, nil
+}
+
+// Provisioned reports whether the table is billed for
+// provisioned throughput.
+func (def *TableDefinition) Provisioned() bool {
+	return def.BillingMode != BillingPayPerRequest
+}
+
+// Validate checks that def names the table and its key, and,
+// unless billed per request, its read and write capacity. The
+// global indexes which don't specify their throughput default
+// to that of the table, like with DefineTable.
+func (def *TableDefinition) Validate() error {
+	if def.TableName == "" || len(def.KeySchema) == 0 {
+		return errors.New("dynamodb: table definition needs a TableName and a KeySchema")
+	}
+	if def.Provisioned() && (def.ReadCapacity <= 0 || def.WriteCapacity <= 0) {
+		return fmt.Errorf("dynamodb: table %s needs a ReadCapacity and a WriteCapacity unless billed per request", def.TableName)
+	}
+	def.defaultIndexes()
+	return nil
+}
+
+// defaultIndexes sets the throughput of the global indexes of
+// a provisioned table which don't specify their own to that of
+// the table.
+func (def *TableDefinition) defaultIndexes() {
+	if !def.Provisioned() {
+		return
+	}
+	for i := range def.GlobalIndexes {
+		index := &def.GlobalIndexes[i]
+		if index.ProvisionedThroughput == (ProvisionedThroughput{}) {
+			index.ProvisionedThroughput = ProvisionedThroughput{def.ReadCapacity, def.WriteCapacity}
+		}
+	}
+}
+
+// CreateTableFrom creates the table defined by def. If def sets
+// EnableTimeToLive, it waits for the table to become active to
+// enable time to live on the TimeToLiveAttribute.
+func (c *Client) CreateTableFrom(ctx context.Context, def *TableDefinition) (*TableDesc, error) {
+	args := Map{
+		"TableName":            def.TableName,
+		"KeySchema":            def.KeySchema,
+		"AttributeDefinitions": def.AttributeDefinitions,
+	}
+	if def.Provisioned() {
+		args["ProvisionedThroughput"] = ProvisionedThroughput{def.ReadCapacity, def.WriteCapacity}
+	}
+	if def.BillingMode != "" {
+		args["BillingMode"] = def.BillingMode
+	}
+	if len(def.GlobalIndexes) > 0 {
+		var indexes []Map
+		for _, index := range def.GlobalIndexes {
+			indexes = append(indexes, globalIndexArgs(def, index))
+		}
+		args["GlobalSecondaryIndexes"] = indexes
+	}
+	if len(def.LocalIndexes) > 0 {
+		args["LocalSecondaryIndexes"] = def.LocalIndexes
+	}
+	if def.Stream != nil {
+		args["StreamSpecification"] = def.Stream
+	}
+	if def.SSE != nil {
+		args["SSESpecification"] = def.SSE
+	}
+	if len(def.Tags) > 0 {
+		args["Tags"] = def.Tags
+	}
+	if def.TableClass != "" {
+		args["TableClass"] = def.TableClass
+	}
+
+	payload, err := c.Call(ctx, "CreateTable", args)
 	if err != nil {
 		return nil, err
 	}
 
 	var t TableResponseWrapper
-	err = json.Unmarshal(payload, &t)
-	return &t.TableDescription, err
+	if err = json.Unmarshal(payload, &t); err != nil {
+		return &t.TableDescription, err
+	}
+	c.Table(def.TableName).cache(&t.TableDescription)
+	if !def.EnableTimeToLive || def.TimeToLiveAttribute == "" {
+		return &t.TableDescription, nil
+	}
+	desc, err := c.WaitUntilActive(ctx, def.TableName)
+	if err != nil {
+		return nil, err
+	}
+	_, err = c.UpdateTimeToLive(ctx, def.TableName, TimeToLiveSpecification{true, def.TimeToLiveAttribute})
+	return desc, err
+}
+
+// globalIndexArgs encodes a global index of def, without
+// throughput unless it is provisioned.
+func globalIndexArgs(def *TableDefinition, index GlobalIndex) Map {
+	m := Map{
+		"IndexName":  index.IndexName,
+		"KeySchema":  index.KeySchema,
+		"Projection": index.Projection,
+	}
+	if def.Provisioned() {
+		m["ProvisionedThroughput"] = index.ProvisionedThroughput
+	}
+	return m
 }
 
+// hasIndex reports whether opts specify the index called name.
+func hasIndex(opts *TableOptions, name string) bool {
+	if opts == nil {
+		return false
+	}
+	for _, index := range opts.GlobalIndexes {
+		if index.IndexName == name {
+			return true
+		}
+	}
+	for _, index := range opts.LocalIndexes {
+		if index.IndexName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ListTables lists up to limit tables, or up to 100 if limit
+// is 0, after the table called cursor, if any. The
+// LastEvaluatedTableName of the list is the cursor of the next
+// page, empty on the last one. See EachTable to list them all.
 func (c *Client) ListTables(
 	ctx context.Context,
 	limit int,
@@ -576,7 +1297,7 @@ func (c *Client) ListTables(
 		args["Limit"] = limit
 	}
 	if cursor != "" {
-		args["ExclusiveStartTable"] = cursor
+		args["ExclusiveStartTableName"] = cursor
 	}
 	payload, err := c.Call(ctx, "ListTables", args)
 	if err != nil {
@@ -586,6 +1307,8 @@ func (c *Client) ListTables(
 	return
 }
 
+// DescribeTable describes the table called name, caching its
+// key schema in the Client's Table of that name.
 func (c *Client) DescribeTable(
 	ctx context.Context,
 	name string,
@@ -595,7 +1318,9 @@ func (c *Client) DescribeTable(
 		return nil, err
 	}
 	var t TableDescWrapper
-	err = json.Unmarshal(payload, &t)
+	if err = json.Unmarshal(payload, &t); err == nil {
+		c.Table(name).cache(&t.Table)
+	}
 	return &t.Table, err
 }
 
@@ -607,11 +1332,17 @@ func (c *Client) DeleteTable(
 	if err != nil {
 		return nil, err
 	}
+	if t := c.lookup(name); t != nil {
+		t.cache(nil)
+	}
 	var t TableResponseWrapper
 	err = json.Unmarshal(payload, &t)
 	return &t.TableDescription, err
 }
 
+// UpdateTable changes the provisioned throughput of the table
+// called name, unless both capacities are 0, and its global
+// indexes. See UpdateTableWith for other changes.
 func (c *Client) UpdateTable(
 	ctx context.Context,
 	name string,
@@ -619,17 +1350,38 @@ func (c *Client) UpdateTable(
 	WriteCapacityUnits int,
 	IndexUpdates []GlobalIndexUpdate,
 ) (*TableDesc, error) {
-	tabUp := TableUpdate{
-		IndexUpdates,
-		ProvisionedThroughput{ReadCapacityUnits, WriteCapacityUnits},
-		name,
+	update := &TableUpdate{GlobalSecondaryIndexUpdates: IndexUpdates}
+	if ReadCapacityUnits != 0 || WriteCapacityUnits != 0 {
+		update.ProvisionedThroughput = &ProvisionedThroughput{ReadCapacityUnits, WriteCapacityUnits}
 	}
-	payload, err := c.Call(ctx, "UpdateTable", tabUp)
+	return c.UpdateTableWith(ctx, name, update)
+}
+
+// UpdateTableWith sends the changes set in update to the table
+// called name, e.g.
+//
+//     desc, err := c.UpdateTableWith(ctx, "users", &dynamodb.TableUpdate{
+//         AttributeDefinitions: []dynamodb.AttributeDefinition{{"email", "S"}},
+//         GlobalSecondaryIndexUpdates: []dynamodb.GlobalIndexUpdate{
+//             dynamodb.CreateIndex(byEmail),
+//         },
+//     })
+//
+// Created indexes need the definitions of their key attributes.
+// DynamoDB only allows one index to be created or deleted per
+// call. The table is UPDATING until the changes are done, see
+// WaitUntilActive.
+func (c *Client) UpdateTableWith(ctx context.Context, name string, update *TableUpdate) (*TableDesc, error) {
+	args := *update
+	args.TableName = name
+	payload, err := c.Call(ctx, "UpdateTable", &args)
 	if err != nil {
 		return nil, err
 	}
 	var t TableResponseWrapper
-	err = json.Unmarshal(payload, &t)
+	if err = json.Unmarshal(payload, &t); err == nil {
+		c.Table(name).cache(&t.TableDescription)
+	}
 	return &t.TableDescription, err
 }
 
@@ -656,26 +1408,108 @@ func (c *Client) CallBytes(
 	method string,
 	payload []byte,
 ) ([]byte, error) {
-	attempt := 0
+	var tables []string
+	if c.observed() || c.handler != nil {
+		tables = requestTables(payload)
+	}
+	return c.call(ctx, method, tables, payload)
+}
+
+// call makes and retries request on behalf of tables
+func (c *Client) call(
+	ctx context.Context,
+	method string,
+	tables []string,
+	payload []byte,
+) ([]byte, error) {
+	observed := c.observed()
+	if observed {
+		payload = withConsumedCapacity(method, payload)
+	}
+	info := &CallInfo{
+		Operation: method,
+		Tables:    tables,
+	}
+	var span Span
+	if c.Tracer != nil {
+		ctx, span = c.Tracer.StartSpan(ctx, "DynamoDB."+method)
+	}
+	start := time.Now()
+	b, err := c.retry(ctx, info, payload)
+	info.Latency = time.Since(start)
+	info.Err = err
+	if err == nil && observed {
+		info.ConsumedCapacity = consumedCapacity(b)
+	}
+	if c.Limiter != nil && err == nil {
+		c.Limiter.consumed(tables, info.ConsumedCapacity)
+	}
+	if c.Metrics != nil {
+		c.Metrics.ObserveCall(info)
+	}
+	if span != nil {
+		endSpan(span, info)
+	}
+	return b, err
+}
+
+// observed returns whether anything consumes the CallInfo of
+// calls and so needs their tables and consumed capacity.
+func (c *Client) observed() bool {
+	return c.Limiter != nil || c.Metrics != nil || c.Tracer != nil
+}
+
+// retry sends request until it succeeds, fails with an error
+// that is not safe to retry or exhausts c.Retry
+func (c *Client) retry(
+	ctx context.Context,
+	info *CallInfo,
+	payload []byte,
+) ([]byte, error) {
 	for {
-		b, err := c.callRaw(ctx, method, payload)
+		if c.Limiter != nil {
+			if err := c.Limiter.wait(ctx, info.Tables); err != nil {
+				return nil, err
+			}
+		}
+		info.Attempts++
+		req := &Request{
+			Operation: info.Operation,
+			Tables:    info.Tables,
+			Payload:   payload,
+			Attempt:   info.Attempts,
+		}
+		var b []byte
+		var err error
+		if c.handler != nil {
+			b, err = c.handler(ctx, req)
+		} else {
+			b, err = c.callRaw(ctx, req)
+		}
 		if err == nil {
+			info.ErrorType = ""
 			return b, nil
 		}
 
-		if e, ok := err.(Error); ok {
-			if e.Retry() {
-				attempt++
-				if attempt >= c.Retry {
-					return nil, ErrRetryExhausted
-				}
-				c.backoff(attempt)
-			} else {
-				return nil, e
-			}
-		} else {
+		e, ok := err.(Error)
+		if !ok {
+			info.ErrorType = ClientErrorType
 			return nil, err
 		}
+		info.ErrorType = e.Type()
+		if e.Throttled() {
+			info.Throttles++
+			if c.Limiter != nil {
+				c.Limiter.throttled(info.Tables)
+			}
+		}
+		if !e.Retry() {
+			return nil, e
+		}
+		if info.Attempts >= c.Retry {
+			return nil, ErrRetryExhausted
+		}
+		c.backoff(info.Attempts)
 	}
 }
 
@@ -688,14 +1522,14 @@ func (c *Client) backoff(attempt int) {
 
 func (c *Client) callRaw(
 	ctx context.Context,
-	method string,
-	payload []byte,
+	r *Request,
 ) ([]byte, error) {
 	// new request
-	req, err := c.newRequest(method, payload)
+	req, reqBody, err := c.newRequest(r.Operation, r.Payload)
 	if err != nil {
 		return nil, err
 	}
+	defer reqBody.release()
 
 	// send request
 	resp, err := c.do(ctx, req)
@@ -714,38 +1548,85 @@ func (c *Client) callRaw(
 			Body:       body,
 			StatusCode: resp.StatusCode,
 		}
-		log.Printf("%v", string(body))
+		if c.ErrorLog != nil && ctx.Value(quietKey{}) == nil {
+			c.ErrorLog.Printf("%v", string(body))
+		}
 		return nil, err
 	}
 	return body, nil
 }
 
-// TODO: use a buffer to reduce string allocations like bmizerany/aws4
+// newRequest signs a request sending payload, building the
+// canonical request and string to sign in a pooled buffer.
+// The returned body must be released once the call is done.
 func (c *Client) newRequest(
 	method string,
 	payload []byte,
-) (*http.Request, error) {
-	req, err := http.NewRequest("POST", c.endpoint.url, bytes.NewReader(payload))
+) (*http.Request, *requestBody, error) {
+	body := &requestBody{}
+	body.r.Reset(payload)
+	req, err := http.NewRequest("POST", c.endpoint.url, body)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	hasher := sha256.New()
-	hasher.Write(payload)
+	req.ContentLength = int64(len(payload))
 	datetime := time.Now().UTC().Format(iso8601)
 	date := datetime[:8]
-	method = "DynamoDB_20120810." + method
-	canonicalReq := "POST\n/\n\ncontent-type:application/x-amz-json-1.0\nhost:" + c.endpoint.host + "\nx-amz-date:" + datetime + "\nx-amz-target:" + method + "\n\ncontent-type;host;x-amz-date;x-amz-target\n" + hex.EncodeToString(hasher.Sum(nil))
-	hasher.Reset()
-	hasher.Write([]byte(canonicalReq))
-	post := "AWS4-HMAC-SHA256\n" + datetime + "\n" + date + "/" + c.endpoint.region + "/dynamodb/aws4_request\n" + hex.EncodeToString(hasher.Sum(nil))
-	sig := hex.EncodeToString(doHMAC(doHMAC(doHMAC(doHMAC(doHMAC(c.auth.secretKey, date), c.endpoint.region), "dynamodb"), "aws4_request"), post))
-	credential := "AWS4-HMAC-SHA256 Credential=" + c.auth.accessKey + "/" + date + "/" + c.endpoint.region + "/dynamodb/aws4_request, SignedHeaders=content-type;host;x-amz-date;x-amz-target, Signature=" + sig
-	req.Header.Set("Authorization", credential)
+	method = c.target + method
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+	hash := sha256.Sum256(payload)
+	buf.WriteString("POST\n/\n\ncontent-type:application/x-amz-json-1.0\nhost:")
+	buf.WriteString(c.endpoint.host)
+	buf.WriteString("\nx-amz-date:")
+	buf.WriteString(datetime)
+	buf.WriteString("\nx-amz-target:")
+	buf.WriteString(method)
+	buf.WriteString("\n\ncontent-type;host;x-amz-date;x-amz-target\n")
+	writeHex(buf, hash[:])
+	hash = sha256.Sum256(buf.Bytes())
+
+	buf.Reset()
+	buf.WriteString("AWS4-HMAC-SHA256\n")
+	buf.WriteString(datetime)
+	buf.WriteByte('\n')
+	buf.WriteString(date)
+	buf.WriteByte('/')
+	buf.WriteString(c.endpoint.region)
+	buf.WriteString("/dynamodb/aws4_request\n")
+	writeHex(buf, hash[:])
+	h := hmac.New(sha256.New, c.signingKey(date))
+	h.Write(buf.Bytes())
+	sig := h.Sum(hash[:0])
+
+	buf.Reset()
+	buf.WriteString("AWS4-HMAC-SHA256 Credential=")
+	buf.WriteString(c.auth.accessKey)
+	buf.WriteByte('/')
+	buf.WriteString(date)
+	buf.WriteByte('/')
+	buf.WriteString(c.endpoint.region)
+	buf.WriteString("/dynamodb/aws4_request, SignedHeaders=content-type;host;x-amz-date;x-amz-target, Signature=")
+	writeHex(buf, sig)
+	req.Header.Set("Authorization", buf.String())
 	req.Header.Set("Content-Type", "application/x-amz-json-1.0")
 	req.Header.Set("Host", c.endpoint.host)
 	req.Header.Set("X-Amz-Date", datetime)
 	req.Header.Set("X-Amz-Target", method)
-	return req, nil
+	return req, body, nil
+}
+
+// signingKey returns the key derived from the secret key for
+// signing requests on date, which only changes once a day.
+func (c *Client) signingKey(date string) []byte {
+	c.keyMu.Lock()
+	defer c.keyMu.Unlock()
+	if c.keyDate != date {
+		c.key = doHMAC(doHMAC(doHMAC(doHMAC(c.auth.secretKey, date), c.endpoint.region), "dynamodb"), "aws4_request")
+		c.keyDate = date
+	}
+	return c.key
 }
 
 // do sends request and enforces context deadline
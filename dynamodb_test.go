@@ -35,13 +35,20 @@ func TestDynamo(t *testing.T) {
 	ctx := context.Background()
 
 	// DeleteTable
-	client.DeleteTable(ctx, "Test")
+	if _, err := client.DeleteTable(ctx, "Test"); err == nil {
+		if err := client.WaitUntilDeleted(ctx, "Test"); err != nil {
+			t.Fatal(err)
+		}
+	}
 
 	// CreateTable
 	_, err = client.CreateTable(ctx, "Test", &MyItem{}, 10, 10, nil, nil)
 	if err != nil {
 		t.Error(err)
 	}
+	if _, err := client.WaitUntilActive(ctx, "Test"); err != nil {
+		t.Fatal(err)
+	}
 
 	// DescribeTable
 	desc, err := client.DescribeTable(ctx, "Test")
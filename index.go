@@ -0,0 +1,163 @@
+// Public Domain (-) 2012-2013 The Go DynamoDB Authors.
+// See the Go DynamoDB UNLICENSE file for details.
+
+package dynamodb
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Projection types of secondary indexes.
+const (
+	ProjectAll      = "ALL"
+	ProjectKeysOnly = "KEYS_ONLY"
+	ProjectInclude  = "INCLUDE"
+)
+
+// modelIndex is a secondary index declared by tags.
+type modelIndex struct {
+	global     bool
+	keys       []KeyItem
+	projection Projection
+}
+
+// modelIndexes derives the secondary indexes declared by the
+// tags of item, a pointer to a struct.
+func modelIndexes(item interface{}) ([]GlobalIndex, []Index, error) {
+	fields, _ := getTypeInfo(item)
+	var names []string
+	indexes := map[string]*modelIndex{}
+	var hash string
+	var projected []string
+	for _, field := range fields {
+		if field.keyType == "HASH" {
+			hash = field.name
+		}
+		for _, option := range field.options {
+			split := strings.SplitN(option, "=", 2)
+			if len(split) != 2 {
+				continue
+			}
+			switch split[0] {
+			case "gsi", "lsi":
+			case "project":
+				projected = append(projected, split[1], field.name)
+				continue
+			default:
+				continue
+			}
+			spec := strings.Split(split[1], ":")
+			if len(spec) < 2 || len(spec) > 3 || spec[0] == "" {
+				return nil, nil, fmt.Errorf("dynamodb: invalid index %q on field %s", option, field.name)
+			}
+			name, keyType := spec[0], spec[1]
+			index := indexes[name]
+			if index == nil {
+				index = &modelIndex{global: split[0] == "gsi"}
+				indexes[name] = index
+				names = append(names, name)
+			}
+			switch {
+			case index.global != (split[0] == "gsi"):
+				return nil, nil, fmt.Errorf("dynamodb: index %s is both global and local", name)
+			case keyType != "HASH" && keyType != "RANGE", !index.global && keyType != "RANGE":
+				return nil, nil, fmt.Errorf("dynamodb: invalid key type %s of index %s on field %s", keyType, name, field.name)
+			}
+			for _, key := range index.keys {
+				if key.KeyType == keyType {
+					return nil, nil, fmt.Errorf("dynamodb: index %s has two %s keys", name, keyType)
+				}
+			}
+			key := KeyItem{field.name, keyType}
+			if keyType == "HASH" {
+				index.keys = append([]KeyItem{key}, index.keys...)
+			} else {
+				index.keys = append(index.keys, key)
+			}
+			if len(spec) == 3 {
+				if index.projection.ProjectionType != "" && index.projection.ProjectionType != spec[2] {
+					return nil, nil, fmt.Errorf("dynamodb: index %s has two projections", name)
+				}
+				index.projection.ProjectionType = spec[2]
+			}
+		}
+	}
+
+	for i := 0; i < len(projected); i += 2 {
+		index := indexes[projected[i]]
+		if index == nil {
+			return nil, nil, fmt.Errorf("dynamodb: field %s is projected into unknown index %s", projected[i+1], projected[i])
+		}
+		index.projection.NonKeyAttributes = append(index.projection.NonKeyAttributes, projected[i+1])
+	}
+
+	var globals []GlobalIndex
+	var locals []Index
+	for _, name := range names {
+		index := indexes[name]
+		projection := &index.projection
+		switch projection.ProjectionType {
+		case "":
+			projection.ProjectionType = ProjectAll
+			if len(projection.NonKeyAttributes) > 0 {
+				projection.ProjectionType = ProjectInclude
+			}
+		case ProjectAll, ProjectKeysOnly:
+			if len(projection.NonKeyAttributes) > 0 {
+				return nil, nil, fmt.Errorf("dynamodb: index %s projects %s and specific attributes", name, projection.ProjectionType)
+			}
+		case ProjectInclude:
+		default:
+			return nil, nil, fmt.Errorf("dynamodb: invalid projection %s of index %s", projection.ProjectionType, name)
+		}
+		if !index.global {
+			if hash == "" {
+				return nil, nil, fmt.Errorf("dynamodb: local index %s needs a HASH key on the table", name)
+			}
+			keys := append([]KeyItem{{hash, "HASH"}}, index.keys...)
+			locals = append(locals, Index{name, keys, *projection})
+			continue
+		}
+		if index.keys[0].KeyType != "HASH" {
+			return nil, nil, fmt.Errorf("dynamodb: global index %s has no HASH key", name)
+		}
+		globals = append(globals, GlobalIndex{Index: Index{name, index.keys, *projection}})
+	}
+	return globals, locals, nil
+}
+
+// modelKeySchema derives the key schema of the index of item,
+// or of its table if index is empty, from its tags. It returns
+// nil if item declares no such index.
+func modelKeySchema(item interface{}, index string) (keySchema, error) {
+	fields, _ := getTypeInfo(item)
+	var keys []KeyItem
+	if index == "" {
+		for _, field := range fields {
+			if field.keyType != "" {
+				keys = append(keys, KeyItem{field.name, field.keyType})
+			}
+		}
+	} else {
+		globals, locals, err := modelIndexes(item)
+		if err != nil {
+			return nil, err
+		}
+		for _, gsi := range globals {
+			if gsi.IndexName == index {
+				keys = gsi.KeySchema
+			}
+		}
+		for _, lsi := range locals {
+			if lsi.IndexName == index {
+				keys = lsi.KeySchema
+			}
+		}
+	}
+	var defs []AttributeDefinition
+	for _, field := range fields {
+		defs = append(defs, AttributeDefinition{field.name, kindMap[field.kind]})
+	}
+	return newKeySchema(keys, defs), nil
+}
@@ -0,0 +1,194 @@
+// Public Domain (-) 2012-2013 The Go DynamoDB Authors.
+// See the Go DynamoDB UNLICENSE file for details.
+
+package dynamodb
+
+import (
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// EachTable calls fn with the name of every table, following
+// LastEvaluatedTableName across pages, until fn returns an
+// error, which EachTable then returns.
+func (c *Client) EachTable(ctx context.Context, fn func(name string) error) error {
+	cursor := ""
+	for {
+		tables, err := c.ListTables(ctx, 0, cursor)
+		if err != nil {
+			return err
+		}
+		for _, name := range tables.TableNames {
+			if err := fn(name); err != nil {
+				return err
+			}
+		}
+		if tables.LastEvaluatedTableName == "" {
+			return nil
+		}
+		cursor = tables.LastEvaluatedTableName
+	}
+}
+
+// TableNames returns the names of all tables.
+func (c *Client) TableNames(ctx context.Context) ([]string, error) {
+	var names []string
+	err := c.EachTable(ctx, func(name string) error {
+		names = append(names, name)
+		return nil
+	})
+	return names, err
+}
+
+// TableInfo summarizes the description of a table.
+type TableInfo struct {
+	Name       string
+	Status     string
+	SizeBytes  int
+	ItemCount  int
+	Throughput ProvisionedThroughput
+	Indexes    []IndexInfo
+
+	// Desc is the complete description of the table.
+	Desc *TableDesc
+}
+
+// IndexInfo summarizes the description of a secondary index.
+// Local indexes have no status and share the throughput of
+// their table.
+type IndexInfo struct {
+	Name       string
+	Global     bool
+	Status     string
+	SizeBytes  int
+	ItemCount  int
+	Throughput ProvisionedThroughput
+}
+
+// Active reports whether the table and its global indexes are
+// ACTIVE.
+func (t *TableInfo) Active() bool {
+	return active(t.Desc)
+}
+
+// newTableInfo summarizes desc.
+func newTableInfo(desc *TableDesc) *TableInfo {
+	info := &TableInfo{
+		Name:       desc.TableName,
+		Status:     desc.TableStatus,
+		SizeBytes:  desc.TableSizeBytes,
+		ItemCount:  desc.ItemCount,
+		Throughput: desc.ProvisionedThroughput.ProvisionedThroughput,
+		Desc:       desc,
+	}
+	for _, index := range desc.GlobalSecondaryIndexes {
+		info.Indexes = append(info.Indexes, IndexInfo{
+			Name:       index.IndexName,
+			Global:     true,
+			Status:     index.IndexStatus,
+			SizeBytes:  index.IndexSizeBytes,
+			ItemCount:  index.ItemCount,
+			Throughput: index.ProvisionedThroughput.ProvisionedThroughput,
+		})
+	}
+	for _, index := range desc.LocalSecondaryIndexes {
+		info.Indexes = append(info.Indexes, IndexInfo{
+			Name:      index.IndexName,
+			SizeBytes: index.IndexSizeBytes,
+			ItemCount: index.ItemCount,
+		})
+	}
+	return info
+}
+
+// Inventory describes every table, with up to concurrency
+// DescribeTable calls at once, or 8 if concurrency is 0, and
+// returns them in the order of ListTables. Tables deleted in the
+// meantime are left out. DynamoDB updates the size and item
+// count of tables about every six hours.
+func (c *Client) Inventory(ctx context.Context, concurrency int) ([]*TableInfo, error) {
+	if concurrency <= 0 {
+		concurrency = 8
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type table struct {
+		index int
+		name  string
+	}
+	type result struct {
+		index int
+		info  *TableInfo
+		err   error
+	}
+	tables := make(chan table)
+	results := make(chan result)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range tables {
+				desc, err := c.DescribeTable(ctx, t.name)
+				r := result{index: t.index}
+				switch {
+				case err == nil:
+					r.info = newTableInfo(desc)
+				case !notFound(err):
+					r.err = err
+				}
+				results <- r
+			}
+		}()
+	}
+
+	// list the tables while they are described
+	listed := make(chan error, 1)
+	go func() {
+		defer close(tables)
+		index := 0
+		listed <- c.EachTable(ctx, func(name string) error {
+			select {
+			case tables <- table{index, name}:
+				index++
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var infos []*TableInfo
+	var err error
+	for r := range results {
+		if r.err != nil && err == nil {
+			err = r.err
+			cancel()
+		}
+		for len(infos) <= r.index {
+			infos = append(infos, nil)
+		}
+		infos[r.index] = r.info
+	}
+	if listErr := <-listed; err == nil {
+		err = listErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// drop deleted tables
+	found := infos[:0]
+	for _, info := range infos {
+		if info != nil {
+			found = append(found, info)
+		}
+	}
+	return found, nil
+}
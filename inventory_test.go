@@ -0,0 +1,115 @@
+package dynamodb
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+// listTablesHandler serves ListTables in pages of two of the
+// tables a to e, and DescribeTable for all of them but c.
+func listTablesHandler(t *testing.T) http.HandlerFunc {
+	names := []string{"a", "b", "c", "d", "e"}
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var args map[string]interface{}
+		json.Unmarshal(body, &args)
+		switch r.Header.Get("X-Amz-Target") {
+		case "DynamoDB_20120810.ListTables":
+			start := 0
+			if cursor, ok := args["ExclusiveStartTableName"].(string); ok {
+				for i, name := range names {
+					if name == cursor {
+						start = i + 1
+					}
+				}
+			}
+			if _, ok := args["ExclusiveStartTable"]; ok {
+				t.Error("sent ExclusiveStartTable")
+			}
+			end := start + 2
+			list := TablesList{}
+			if end < len(names) {
+				list.LastEvaluatedTableName = names[end-1]
+			} else {
+				end = len(names)
+			}
+			list.TableNames = names[start:end]
+			json.NewEncoder(w).Encode(list)
+		case "DynamoDB_20120810.DescribeTable":
+			name := args["TableName"].(string)
+			if name == "c" {
+				w.WriteHeader(400)
+				fmt.Fprint(w, `{"__type":"com.amazonaws.dynamodb.v20120810#ResourceNotFoundException","message":"not found"}`)
+				return
+			}
+			fmt.Fprintf(w, `{"Table":{
+				"TableName":%q,
+				"TableStatus":"ACTIVE",
+				"TableSizeBytes":100,
+				"ItemCount":2,
+				"ProvisionedThroughput":{"ReadCapacityUnits":5,"WriteCapacityUnits":1},
+				"GlobalSecondaryIndexes":[{"IndexName":"ByName","IndexStatus":"CREATING","IndexSizeBytes":10,"ItemCount":1}],
+				"LocalSecondaryIndexes":[{"IndexName":"ByTime","IndexSizeBytes":20,"ItemCount":2}]
+			}}`, name)
+		}
+	}
+}
+
+func TestEachTable(t *testing.T) {
+	server, client := newTestClient(listTablesHandler(t))
+	defer server.Close()
+
+	names, err := client.TableNames(context.Background())
+	if want := []string{"a", "b", "c", "d", "e"}; err != nil || !reflect.DeepEqual(names, want) {
+		t.Error("want", want)
+		t.Error("got ", names, err)
+	}
+
+	// fn stops the walk
+	stop := fmt.Errorf("stop")
+	var seen []string
+	err = client.EachTable(context.Background(), func(name string) error {
+		seen = append(seen, name)
+		if name == "c" {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || strings.Join(seen, "") != "abc" {
+		t.Error("got", seen, err)
+	}
+}
+
+func TestInventory(t *testing.T) {
+	server, client := newTestClient(listTablesHandler(t))
+	defer server.Close()
+	client.ErrorLog = nil
+
+	infos, err := client.Inventory(context.Background(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, info := range infos {
+		names = append(names, info.Name)
+	}
+	if want := []string{"a", "b", "d", "e"}; !reflect.DeepEqual(names, want) {
+		t.Error("want", want)
+		t.Error("got ", names)
+	}
+	info := infos[0]
+	want := []IndexInfo{
+		{Name: "ByName", Global: true, Status: "CREATING", SizeBytes: 10, ItemCount: 1},
+		{Name: "ByTime", SizeBytes: 20, ItemCount: 2},
+	}
+	if info.SizeBytes != 100 || info.ItemCount != 2 || info.Throughput != (ProvisionedThroughput{5, 1}) || info.Active() || !reflect.DeepEqual(info.Indexes, want) {
+		t.Error("got", info)
+	}
+}
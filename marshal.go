@@ -6,7 +6,6 @@ package dynamodb
 import (
 	"bytes"
 	"encoding/base64"
-	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
@@ -32,30 +31,35 @@ const (
 	uintSetField
 	uint64Field
 	uint64SetField
+	ttlField
+	ttlDurationField
 )
 
 var kindMap = [...]string{
-	binaryField:    "B",
-	binarySetField: "BS",
-	boolField:      "N",
-	boolSetField:   "NS",
-	intField:       "N",
-	intSetField:    "NS",
-	int64Field:     "N",
-	int64SetField:  "NS",
-	stringField:    "S",
-	stringSetField: "SS",
-	timeField:      "N",
-	uintField:      "N",
-	uintSetField:   "NS",
-	uint64Field:    "N",
-	uint64SetField: "NS",
+	binaryField:      "B",
+	binarySetField:   "BS",
+	boolField:        "N",
+	boolSetField:     "NS",
+	intField:         "N",
+	intSetField:      "NS",
+	int64Field:       "N",
+	int64SetField:    "NS",
+	stringField:      "S",
+	stringSetField:   "SS",
+	timeField:        "N",
+	uintField:        "N",
+	uintSetField:     "NS",
+	uint64Field:      "N",
+	uint64SetField:   "NS",
+	ttlField:         "N",
+	ttlDurationField: "N",
 }
 
 var (
-	mutex    sync.RWMutex
-	timeType = reflect.TypeOf(time.Time{})
-	typeInfo = map[reflect.Type][]*fieldInfo{}
+	mutex        sync.RWMutex
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	typeInfo     = map[reflect.Type][]*fieldInfo{}
 )
 
 type fieldInfo struct {
@@ -63,6 +67,7 @@ type fieldInfo struct {
 	index   int
 	name    string
 	keyType string
+	options []string
 }
 
 func getTypeInfo(v interface{}) ([]*fieldInfo, reflect.Value) {
@@ -89,16 +94,28 @@ func Marshal(v interface{}) ([]byte, error) {
 }
 
 func encode(v interface{}, buf *bytes.Buffer, asKey bool, expected bool) {
-	if item, ok := v.(Item); ok {
-		item.Encode(buf)
-		return
+	switch {
+	case asKey:
+		if item, ok := v.(KeyEncoder); ok {
+			item.EncodeKey(buf)
+			return
+		}
+	case expected:
+		if item, ok := v.(ExpectedEncoder); ok {
+			item.EncodeExpected(buf)
+			return
+		}
+	default:
+		if item, ok := v.(Item); ok {
+			item.Encode(buf)
+			return
+		}
 	}
 
 	fields, rv := getTypeInfo(v)
 
-	close := `{"`
-
-	written := false
+	// suffix closes the value of the previous field
+	suffix := ""
 
 	if asKey == true {
 		var keyFields []*fieldInfo
@@ -109,31 +126,35 @@ func encode(v interface{}, buf *bytes.Buffer, asKey bool, expected bool) {
 		}
 		fields = keyFields
 	}
-	last := len(fields) - 1
 	for idx, field := range fields {
 		dbKind := kindMap[field.kind]
-		prefix := `"`
-		suffix := `"`
-
-		if len(dbKind) == 2 {
-			prefix = "["
-			suffix = "]"
-		}
-		if expected == false {
-			fmt.Fprintf(buf, `%s%s":{"%s":%s`, close, field.name, dbKind, prefix)
+		if idx == 0 {
+			buf.WriteString(`{"`)
 		} else {
-			fmt.Fprintf(buf, `%s%s":{"Value":{"%s":%s`, close, field.name, dbKind, prefix)
-		}
-		comma := ","
-		if idx == last {
-			comma = ""
+			buf.WriteString(suffix)
+			buf.WriteString(`,"`)
 		}
+		buf.WriteString(field.name)
 		if expected == false {
-			close = fmt.Sprintf(`%s}%s"`, suffix, comma)
+			buf.WriteString(`":{"`)
 		} else {
-			close = fmt.Sprintf(`%s}}%s"`, suffix, comma)
+			buf.WriteString(`":{"Value":{"`)
+		}
+		buf.WriteString(dbKind)
+		switch {
+		case len(dbKind) == 2 && expected:
+			buf.WriteString(`":[`)
+			suffix = "]}}"
+		case len(dbKind) == 2:
+			buf.WriteString(`":[`)
+			suffix = "]}"
+		case expected:
+			buf.WriteString(`":"`)
+			suffix = `"}}`
+		default:
+			buf.WriteString(`":"`)
+			suffix = `"}`
 		}
-		written = true
 
 		fv := rv.Field(field.index)
 
@@ -239,12 +260,17 @@ func encode(v interface{}, buf *bytes.Buffer, asKey bool, expected bool) {
 			}
 		case timeField:
 			buf.WriteString(strconv.FormatInt(fv.Interface().(time.Time).UnixNano(), 10))
+		case ttlField:
+			buf.WriteString(strconv.FormatInt(EncodeTTL(fv.Interface().(time.Time)), 10))
+		case ttlDurationField:
+			buf.WriteString(strconv.FormatInt(EncodeTTLDuration(time.Duration(fv.Int())), 10))
 		}
 
 	}
 
-	if written {
-		fmt.Fprintf(buf, "%s}", close[:len(close)-1])
+	if len(fields) > 0 {
+		buf.WriteString(suffix)
+		buf.WriteByte('}')
 	}
 
 }
@@ -260,7 +286,7 @@ func decode(v interface{}, data ResponseItem) {
 
 	for _, field := range fields {
 		switch field.kind {
-		case binaryField, boolField, intField, int64Field, stringField, timeField, uintField, uint64Field:
+		case binaryField, boolField, intField, int64Field, stringField, timeField, uintField, uint64Field, ttlField, ttlDurationField:
 			if val, ok := data[field.name][kindMap[field.kind]].(string); ok {
 				switch field.kind {
 				case binaryField:
@@ -286,15 +312,15 @@ func decode(v interface{}, data ResponseItem) {
 				case uint64Field:
 					tmp, _ := strconv.ParseUint(val, 10, 64)
 					rv.Field(field.index).SetUint(tmp)
+				case ttlField:
+					tmp, _ := strconv.ParseInt(val, 10, 64)
+					rv.Field(field.index).Set(reflect.ValueOf(DecodeTTL(tmp)))
+				case ttlDurationField:
+					tmp, _ := strconv.ParseInt(val, 10, 64)
+					rv.Field(field.index).SetInt(int64(DecodeTTLDuration(tmp)))
 				case timeField:
 					tmp, _ := strconv.ParseInt(val, 10, 64)
-					bin, err := time.Unix(0, tmp).MarshalBinary()
-					if err == nil {
-						if tobj, ok := rv.Field(field.index).Interface().(time.Time); ok {
-							tobj.UnmarshalBinary(bin)
-							rv.Field(field.index).Set(reflect.ValueOf(tobj))
-						}
-					}
+					rv.Field(field.index).Set(reflect.ValueOf(time.Unix(0, tmp).UTC()))
 				}
 			}
 		case binarySetField, boolSetField, intSetField, int64SetField, stringSetField, uintSetField, uint64SetField:
@@ -359,6 +385,23 @@ func decode(v interface{}, data ResponseItem) {
 
 }
 
+// parseTag splits a ddb tag into the attribute name, its key
+// type in the table, if any, and its other options, e.g.
+// gsi=ByEmail:HASH.
+func parseTag(tag string) (name, keyType string, options []string) {
+	split := strings.Split(tag, ",")
+	for _, option := range split[1:] {
+		switch option {
+		case "":
+		case "HASH", "RANGE":
+			keyType = option
+		default:
+			options = append(options, option)
+		}
+	}
+	return split[0], keyType, options
+}
+
 func compile(it reflect.Type) []*fieldInfo {
 
 	if it.Kind() != reflect.Ptr {
@@ -376,20 +419,9 @@ func compile(it reflect.Type) []*fieldInfo {
 		if field.Anonymous {
 			continue
 		}
-		name := ""
-		keyType := ""
-		if tag := field.Tag.Get("ddb"); tag != "" {
-			split := strings.Split(tag, ",")
-			if len(split) == 2 {
-				keyType = split[1]
-				tag = split[0]
-			} else {
-				tag = split[0]
-			}
-			if tag == "-" {
-				continue
-			}
-			name = tag
+		name, keyType, options := parseTag(field.Tag.Get("ddb"))
+		if name == "-" {
+			continue
 		}
 		if name == "" {
 			name = field.Name
@@ -441,11 +473,25 @@ func compile(it reflect.Type) []*fieldInfo {
 		if kind == -1 {
 			panic("dynamodb: unsupported field type: " + field.Type.Elem().Kind().String())
 		}
+		for _, option := range options {
+			if option != "ttl" {
+				continue
+			}
+			switch {
+			case kind == timeField:
+				kind = ttlField
+			case field.Type == durationType:
+				kind = ttlDurationField
+			default:
+				panic("dynamodb: ttl field " + field.Name + " must be a time.Time or time.Duration")
+			}
+		}
 		fields = append(fields, &fieldInfo{
 			kind:    kind,
 			index:   i,
 			name:    name,
 			keyType: keyType,
+			options: options,
 		})
 
 	}
@@ -0,0 +1,275 @@
+// Public Domain (-) 2012-2013 The Go DynamoDB Authors.
+// See the Go DynamoDB UNLICENSE file for details.
+
+package dynamodb
+
+import (
+	"bytes"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ClientErrorType is the ErrorType of calls which failed
+// without a response from DynamoDB, e.g. because of a network
+// error or an expired context.
+const ClientErrorType = "ClientError"
+
+// CallInfo describes a call made by the Client, including all
+// of its retries.
+type CallInfo struct {
+	Operation string
+	Tables    []string
+
+	// Latency is the time spent on the call, including the
+	// time spent waiting for retries and the Limiter.
+	Latency time.Duration
+
+	// Attempts is the number of requests sent and Throttles
+	// the number of those which were throttled.
+	Attempts  int
+	Throttles int
+
+	// ErrorType is the type of the last error as reported by
+	// Error.Type, ClientErrorType for other errors, or empty
+	// if the call succeeded.
+	ErrorType string
+	Err       error
+
+	// ConsumedCapacity is the capacity reported by DynamoDB.
+	ConsumedCapacity []ConsumedCapacity
+}
+
+// MetricsCollector is notified of every call made by a Client
+// once it has completed. Implementations must be safe for
+// concurrent use.
+type MetricsCollector interface {
+	ObserveCall(info *CallInfo)
+}
+
+// LatencyBuckets are the upper bounds, in seconds, of the
+// latency histograms kept by Metrics. NewMetrics copies them, so
+// changing them only affects the Metrics created afterwards.
+var LatencyBuckets = []float64{
+	0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10,
+}
+
+// Metrics is a MetricsCollector which aggregates calls per
+// table and operation. It can be exported with expvar using
+// Publish, or scraped in the Prometheus text format by
+// serving it over HTTP, e.g.
+//
+//     metrics := dynamodb.NewMetrics()
+//     client.Metrics = metrics
+//     metrics.Publish("dynamodb")
+//     http.Handle("/metrics", metrics)
+type Metrics struct {
+	mu      sync.Mutex
+	series  map[seriesKey]*series
+	buckets []float64
+}
+
+type seriesKey struct {
+	table     string
+	operation string
+}
+
+type series struct {
+	calls     int64
+	attempts  int64
+	throttles int64
+	capacity  float64
+	errors    map[string]int64
+	buckets   []int64
+	sum       float64
+}
+
+// NewMetrics creates an empty Metrics.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		series:  map[seriesKey]*series{},
+		buckets: append([]float64(nil), LatencyBuckets...),
+	}
+}
+
+// ObserveCall satisfies the MetricsCollector interface.
+func (m *Metrics) ObserveCall(info *CallInfo) {
+	tables := info.Tables
+	if len(tables) == 0 {
+		tables = []string{""}
+	}
+	latency := info.Latency.Seconds()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, table := range tables {
+		key := seriesKey{table, info.Operation}
+		s, ok := m.series[key]
+		if !ok {
+			s = &series{
+				errors:  map[string]int64{},
+				buckets: make([]int64, len(m.buckets)),
+			}
+			m.series[key] = s
+		}
+		s.calls++
+		s.attempts += int64(info.Attempts)
+		s.throttles += int64(info.Throttles)
+		if info.Err != nil {
+			s.errors[info.ErrorType]++
+		}
+		for _, cc := range info.ConsumedCapacity {
+			if cc.TableName == table {
+				s.capacity += cc.CapacityUnits
+			}
+		}
+		for i, bound := range m.buckets {
+			if latency <= bound {
+				s.buckets[i]++
+			}
+		}
+		s.sum += latency
+	}
+}
+
+// sortedKeys must be called with m.mu held.
+func (m *Metrics) sortedKeys() []seriesKey {
+	keys := make([]seriesKey, 0, len(m.series))
+	for key := range m.series {
+		keys = append(keys, key)
+	}
+	sort.Sort(byTableOperation(keys))
+	return keys
+}
+
+type byTableOperation []seriesKey
+
+func (k byTableOperation) Len() int      { return len(k) }
+func (k byTableOperation) Swap(i, j int) { k[i], k[j] = k[j], k[i] }
+func (k byTableOperation) Less(i, j int) bool {
+	if k[i].table != k[j].table {
+		return k[i].table < k[j].table
+	}
+	return k[i].operation < k[j].operation
+}
+
+// Snapshot returns the current metrics keyed by table and then
+// operation, in a form suitable for encoding as JSON.
+func (m *Metrics) Snapshot() map[string]map[string]Map {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snapshot := map[string]map[string]Map{}
+	for key, s := range m.series {
+		ops, ok := snapshot[key.table]
+		if !ok {
+			ops = map[string]Map{}
+			snapshot[key.table] = ops
+		}
+		errors := map[string]int64{}
+		for errtype, n := range s.errors {
+			errors[errtype] = n
+		}
+		buckets := map[string]int64{}
+		for i, bound := range m.buckets {
+			buckets[strconv.FormatFloat(bound, 'g', -1, 64)] = s.buckets[i]
+		}
+		ops[key.operation] = Map{
+			"Calls":            s.calls,
+			"Attempts":         s.attempts,
+			"Throttles":        s.throttles,
+			"Errors":           errors,
+			"ConsumedCapacity": s.capacity,
+			"LatencySeconds":   s.sum,
+			"LatencyBuckets":   buckets,
+		}
+	}
+	return snapshot
+}
+
+// Publish exports the Snapshot of the metrics as the expvar
+// variable name. Like expvar.Publish, it panics if the name is
+// already registered.
+func (m *Metrics) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return m.Snapshot()
+	}))
+}
+
+// ServeHTTP writes the metrics in the Prometheus text
+// exposition format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	m.WritePrometheus(w)
+}
+
+// WritePrometheus writes the metrics in the Prometheus text
+// exposition format.
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	m.mu.Lock()
+	keys := m.sortedKeys()
+	buf := &bytes.Buffer{}
+
+	counter := func(name, help string, value func(*series) string) {
+		fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+		for _, key := range keys {
+			fmt.Fprintf(buf, "%s{%s} %s\n", name, labels(key), value(m.series[key]))
+		}
+	}
+	counter("dynamodb_calls_total", "Calls made, including their retries.", func(s *series) string {
+		return strconv.FormatInt(s.calls, 10)
+	})
+	counter("dynamodb_attempts_total", "Requests sent, counting every retry.", func(s *series) string {
+		return strconv.FormatInt(s.attempts, 10)
+	})
+	counter("dynamodb_throttles_total", "Requests rejected for exceeding throughput.", func(s *series) string {
+		return strconv.FormatInt(s.throttles, 10)
+	})
+	counter("dynamodb_consumed_capacity_units_total", "Capacity units consumed.", func(s *series) string {
+		return strconv.FormatFloat(s.capacity, 'g', -1, 64)
+	})
+
+	name := "dynamodb_errors_total"
+	fmt.Fprintf(buf, "# HELP %s Calls which failed, by error type.\n# TYPE %s counter\n", name, name)
+	for _, key := range keys {
+		s := m.series[key]
+		errtypes := make([]string, 0, len(s.errors))
+		for errtype := range s.errors {
+			errtypes = append(errtypes, errtype)
+		}
+		sort.Strings(errtypes)
+		for _, errtype := range errtypes {
+			fmt.Fprintf(buf, "%s{%s,type=\"%s\"} %d\n", name, labels(key), escapeLabel(errtype), s.errors[errtype])
+		}
+	}
+
+	name = "dynamodb_call_duration_seconds"
+	fmt.Fprintf(buf, "# HELP %s Latency of calls, including their retries.\n# TYPE %s histogram\n", name, name)
+	for _, key := range keys {
+		s := m.series[key]
+		for i, bound := range m.buckets {
+			fmt.Fprintf(buf, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels(key), strconv.FormatFloat(bound, 'g', -1, 64), s.buckets[i])
+		}
+		fmt.Fprintf(buf, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels(key), s.calls)
+		fmt.Fprintf(buf, "%s_sum{%s} %s\n", name, labels(key), strconv.FormatFloat(s.sum, 'g', -1, 64))
+		fmt.Fprintf(buf, "%s_count{%s} %d\n", name, labels(key), s.calls)
+	}
+	m.mu.Unlock()
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func labels(key seriesKey) string {
+	return fmt.Sprintf(`table="%s",operation="%s"`, escapeLabel(key.table), escapeLabel(key.operation))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
@@ -0,0 +1,89 @@
+package dynamodb
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestMetrics(t *testing.T) {
+	calls := 0
+	server, client := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch calls {
+		case 1:
+			w.WriteHeader(400)
+			w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#ProvisionedThroughputExceededException","message":"slow down"}`))
+		case 2:
+			w.Write([]byte(`{"ConsumedCapacity":{"TableName":"Test","CapacityUnits":1.5}}`))
+		default:
+			w.WriteHeader(400)
+			w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#ConditionalCheckFailedException","message":"failed"}`))
+		}
+	})
+	defer server.Close()
+
+	metrics := NewMetrics()
+	client.Metrics = metrics
+	client.ErrorLog = nil
+	table := client.Table("Test")
+	ctx := context.Background()
+	if err := table.Put(ctx, &MyItem{Name: "Tom"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Add(ctx, &MyItem{Name: "Tom"}); err == nil {
+		t.Fatal("want error")
+	}
+
+	ops := metrics.Snapshot()["Test"]
+	put := ops["PutItem"]
+	if put["Calls"] != int64(2) || put["Attempts"] != int64(3) || put["Throttles"] != int64(1) {
+		t.Error("got", put)
+	}
+	if put["ConsumedCapacity"] != 1.5 {
+		t.Error("want", 1.5)
+		t.Error("got ", put["ConsumedCapacity"])
+	}
+	if errs := put["Errors"].(map[string]int64); errs["ConditionalCheckFailedException"] != 1 {
+		t.Error("got", errs)
+	}
+
+	buf := &bytes.Buffer{}
+	metrics.WritePrometheus(buf)
+	for _, line := range []string{
+		`dynamodb_calls_total{table="Test",operation="PutItem"} 2`,
+		`dynamodb_attempts_total{table="Test",operation="PutItem"} 3`,
+		`dynamodb_throttles_total{table="Test",operation="PutItem"} 1`,
+		`dynamodb_consumed_capacity_units_total{table="Test",operation="PutItem"} 1.5`,
+		`dynamodb_errors_total{table="Test",operation="PutItem",type="ConditionalCheckFailedException"} 1`,
+		`dynamodb_call_duration_seconds_bucket{table="Test",operation="PutItem",le="+Inf"} 2`,
+		`dynamodb_call_duration_seconds_count{table="Test",operation="PutItem"} 2`,
+	} {
+		if !strings.Contains(buf.String(), line+"\n") {
+			t.Error("missing", line)
+		}
+	}
+}
+
+func TestMetricsBuckets(t *testing.T) {
+	defer func(buckets []float64) { LatencyBuckets = buckets }(LatencyBuckets)
+	metrics := NewMetrics()
+	metrics.ObserveCall(&CallInfo{Operation: "GetItem", Latency: time.Millisecond})
+
+	// changing the buckets doesn't affect existing Metrics
+	LatencyBuckets = append(LatencyBuckets, 20, 30)
+	metrics.ObserveCall(&CallInfo{Operation: "GetItem", Latency: time.Millisecond})
+	buf := &bytes.Buffer{}
+	metrics.WritePrometheus(buf)
+	if strings.Contains(buf.String(), `le="20"`) || !strings.Contains(buf.String(), `le="0.005"} 2`) {
+		t.Error("got", buf.String())
+	}
+	if got := len(metrics.Snapshot()[""]["GetItem"]["LatencyBuckets"].(map[string]int64)); got != 11 {
+		t.Error("want", 11)
+		t.Error("got ", got)
+	}
+}
@@ -0,0 +1,86 @@
+// Public Domain (-) 2012-2013 The Go DynamoDB Authors.
+// See the Go DynamoDB UNLICENSE file for details.
+
+package dynamodb
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Request describes a single attempt at a DynamoDB API call.
+// Retried calls produce a new Request for every attempt.
+type Request struct {
+	// Operation is the name of the API call, e.g. "PutItem".
+	Operation string
+
+	// Tables are the names of the tables the call operates on.
+	Tables []string
+
+	// Payload is the JSON body of the call. Middleware may
+	// replace it before passing the Request on; it is signed
+	// only once the innermost Handler sends it. Payloads are
+	// built in pooled buffers, so they must be copied to be
+	// kept after the call returns.
+	Payload []byte
+
+	// Attempt counts the attempts made, starting at 1.
+	Attempt int
+}
+
+// Handler sends a Request and returns the body of the
+// response.
+type Handler func(ctx context.Context, req *Request) ([]byte, error)
+
+// Middleware wraps the Handler which sends requests over HTTP
+// in order to observe or modify them, e.g.
+//
+//     client.Use(func(next dynamodb.Handler) dynamodb.Handler {
+//         return func(ctx context.Context, req *dynamodb.Request) ([]byte, error) {
+//             start := time.Now()
+//             resp, err := next(ctx, req)
+//             metrics.Observe(req.Operation, time.Since(start), err)
+//             return resp, err
+//         }
+//     })
+type Middleware func(next Handler) Handler
+
+// Use appends middleware to the chain around every request
+// made by the Client. The first middleware added is the
+// outermost one. Use is not safe to call concurrently with
+// requests.
+func (c *Client) Use(middleware ...Middleware) {
+	c.middleware = append(c.middleware, middleware...)
+	h := c.callRaw
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		h = c.middleware[i](h)
+	}
+	c.handler = h
+}
+
+// LogRequests returns Middleware which logs the operation,
+// tables, attempt, latency and error of every request.
+func LogRequests(logger *log.Logger) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) ([]byte, error) {
+			start := time.Now()
+			resp, err := next(ctx, req)
+			status := "ok"
+			if err != nil {
+				status = err.Error()
+			}
+			logger.Printf(
+				"dynamodb: %s table=%s attempt=%d latency=%s status=%q",
+				req.Operation,
+				strings.Join(req.Tables, ","),
+				req.Attempt,
+				time.Since(start),
+				status,
+			)
+			return resp, err
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package dynamodb
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func newTestClient(handler http.HandlerFunc) (*httptest.Server, *Client) {
+	server := httptest.NewServer(handler)
+	u, _ := url.Parse(server.URL)
+	client := Dial(EndPoint("Test", "local", u.Host, false), Auth("key", "secret"), nil)
+	return server, client
+}
+
+func TestMiddleware(t *testing.T) {
+	var body string
+	server, client := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body = string(b)
+		w.Write([]byte(`{}`))
+	})
+	defer server.Close()
+
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, req *Request) ([]byte, error) {
+				order = append(order, name)
+				return next(ctx, req)
+			}
+		}
+	}
+	client.Use(trace("first"), trace("second"))
+	client.Use(func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) ([]byte, error) {
+			if req.Operation != "DescribeTable" {
+				t.Error("want", "DescribeTable")
+				t.Error("got ", req.Operation)
+			}
+			if len(req.Tables) != 1 || req.Tables[0] != "Test" {
+				t.Error("got", req.Tables)
+			}
+			req.Payload = []byte(`{"TableName":"Mutated"}`)
+			return next(ctx, req)
+		}
+	})
+
+	_, err := client.Call(context.Background(), "DescribeTable", Map{"TableName": "Test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(order, ",") != "first,second" {
+		t.Error("got", order)
+	}
+	if body != `{"TableName":"Mutated"}` {
+		t.Error("got", body)
+	}
+}
+
+func TestErrorLog(t *testing.T) {
+	server, client := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(400)
+		w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#ValidationException","message":"bad"}`))
+	})
+	defer server.Close()
+
+	out := &bytes.Buffer{}
+	client.ErrorLog = log.New(out, "", 0)
+	client.Use(LogRequests(log.New(out, "", 0)))
+	_, err := client.Call(context.Background(), "DescribeTable", Map{"TableName": "Test"})
+	if err == nil {
+		t.Fatal("want error")
+	}
+	logged := out.String()
+	if !strings.Contains(logged, `"message":"bad"`) {
+		t.Error("response body not logged:", logged)
+	}
+	if !strings.Contains(logged, "DescribeTable table=Test attempt=1") {
+		t.Error("request not logged:", logged)
+	}
+
+	out.Reset()
+	client.ErrorLog = nil
+	client.Call(context.Background(), "DescribeTable", Map{"TableName": "Test"})
+	if strings.Contains(out.String(), `"message":"bad"`) {
+		t.Error("response body logged with ErrorLog disabled")
+	}
+}
@@ -0,0 +1,467 @@
+// Public Domain (-) 2012-2013 The Go DynamoDB Authors.
+// See the Go DynamoDB UNLICENSE file for details.
+
+// Package migrate reconciles DynamoDB tables with their desired
+// schema. A Spec describes a table, e.g. as derived from the
+// tags of a struct:
+//
+//     spec, err := migrate.NewSpec("users", &User{}, &dynamodb.TableOptions{
+//         BillingMode: dynamodb.BillingPayPerRequest,
+//     })
+//
+// Plan compares it to the table as described by DynamoDB and
+// lists the steps which migrate the table, which Apply then
+// takes one by one, waiting for the table and its indexes to
+// become active after each:
+//
+//     steps, err := migrate.Plan(ctx, client, spec)
+//     for _, step := range steps {
+//         fmt.Println(step)
+//     }
+//     err = migrate.Apply(ctx, client, steps, nil)
+//
+// DynamoDB only allows one global secondary index to be created
+// or deleted at a time, so every index change is a step of its
+// own. Changes which DynamoDB can't make to an existing table,
+// e.g. to its key or local indexes, fail the Plan.
+package migrate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/groupme/dynamodb-1"
+	"golang.org/x/net/context"
+)
+
+// Spec is the desired schema of a table. Stream and TimeToLive
+// are left as they are unless set. TimeToLive defaults to
+// enabling time to live on the TimeToLiveAttribute, if any.
+// Tags are added to the table, or changed, but tags it has
+// besides are kept.
+type Spec struct {
+	dynamodb.TableDefinition
+	TimeToLive *dynamodb.TimeToLiveSpecification `json:",omitempty"`
+}
+
+// timeToLive returns the time to live wanted by s, nil if it
+// is left as it is.
+func (s *Spec) timeToLive() *dynamodb.TimeToLiveSpecification {
+	if s.TimeToLive == nil && s.TimeToLiveAttribute != "" {
+		return &dynamodb.TimeToLiveSpecification{Enabled: true, AttributeName: s.TimeToLiveAttribute}
+	}
+	return s.TimeToLive
+}
+
+// NewSpec creates the Spec of the table called name, defined
+// like dynamodb.CreateTableWith does.
+func NewSpec(name string, schemaItem interface{}, opts *dynamodb.TableOptions) (*Spec, error) {
+	def, err := dynamodb.DefineTable(name, schemaItem, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Spec{TableDefinition: *def}, nil
+}
+
+// Step is a single change to a table.
+type Step struct {
+	// Table is the name of the table changed.
+	Table string
+
+	// Action describes the change, e.g. "create index ByEmail".
+	Action string
+
+	apply func(ctx context.Context, c *dynamodb.Client) error
+	wait  bool
+}
+
+func (s *Step) String() string {
+	return s.Table + ": " + s.Action
+}
+
+// Apply applies the steps in order, calling report, unless nil,
+// before each. After every step changing the table, it waits
+// for the table and its indexes to become active again.
+func Apply(ctx context.Context, c *dynamodb.Client, steps []*Step, report func(*Step)) error {
+	for _, step := range steps {
+		if report != nil {
+			report(step)
+		}
+		if err := step.apply(ctx, c); err != nil {
+			return fmt.Errorf("migrate: %s: %v", step, err)
+		}
+		if step.wait {
+			if _, err := c.WaitUntilActive(ctx, step.Table); err != nil {
+				return fmt.Errorf("migrate: %s: %v", step, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Migrate plans the migration of every table in specs and
+// applies it.
+func Migrate(ctx context.Context, c *dynamodb.Client, specs []*Spec, report func(*Step)) error {
+	for _, spec := range specs {
+		steps, err := Plan(ctx, c, spec)
+		if err != nil {
+			return err
+		}
+		if err := Apply(ctx, c, steps, report); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// state is the current state of a table.
+type state struct {
+	desc dynamodb.TableDesc
+	ttl  dynamodb.TimeToLiveSpecification
+	tags []dynamodb.Tag
+}
+
+// describe fetches the state of the table called name, or
+// returns nil if it doesn't exist.
+func describe(ctx context.Context, c *dynamodb.Client, name string) (*state, error) {
+	desc, err := c.DescribeTable(ctx, name)
+	if e, ok := err.(dynamodb.Error); ok && e.Type() == "ResourceNotFoundException" {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := &state{desc: *desc}
+
+	ttl, err := c.DescribeTimeToLive(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if ttl.Enabled() {
+		s.ttl = dynamodb.TimeToLiveSpecification{Enabled: true, AttributeName: ttl.AttributeName}
+	}
+	return s, nil
+}
+
+// Plan compares spec to the table as described by DynamoDB and
+// returns the steps which migrate it, none if it is up to date.
+// It fails if spec isn't valid, see dynamodb.TableDefinition's
+// Validate.
+func Plan(ctx context.Context, c *dynamodb.Client, spec *Spec) ([]*Step, error) {
+	current, err := describe(ctx, c, spec.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil && len(spec.Tags) > 0 {
+		if current.tags, err = c.ListTagsOfResource(ctx, current.desc.TableArn); err != nil {
+			return nil, err
+		}
+	}
+	return plan(spec, current)
+}
+
+// plan lists the steps migrating the table from its current
+// state, nil if it doesn't exist, to spec.
+func plan(spec *Spec, current *state) ([]*Step, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, fmt.Errorf("migrate: %v", err)
+	}
+	name := spec.TableName
+	var steps []*Step
+	if current == nil {
+		// time to live is enabled by a step of its own
+		def := spec.TableDefinition
+		def.TimeToLiveAttribute = ""
+		steps = append(steps, &Step{
+			Table:  name,
+			Action: "create table",
+			apply: func(ctx context.Context, c *dynamodb.Client) error {
+				_, err := c.CreateTableFrom(ctx, &def)
+				return err
+			},
+			wait: true,
+		})
+		if ttl := spec.timeToLive(); ttl != nil && ttl.Enabled {
+			steps = append(steps, updateTimeToLive(name, *ttl))
+		}
+		return steps, nil
+	}
+
+	desc := &current.desc
+	if !sameKeys(spec.KeySchema, desc.KeySchema) {
+		return nil, fmt.Errorf("migrate: %s: the key of the table can't be changed", name)
+	}
+	var locals []dynamodb.Index
+	for _, index := range desc.LocalSecondaryIndexes {
+		locals = append(locals, index.Index)
+	}
+	if len(locals) != len(spec.LocalIndexes) {
+		return nil, fmt.Errorf("migrate: %s: local indexes can't be changed", name)
+	}
+	for _, index := range spec.LocalIndexes {
+		if found := findIndex(locals, index.IndexName); found == nil || !sameIndex(*found, index) {
+			return nil, fmt.Errorf("migrate: %s: local index %s can't be changed", name, index.IndexName)
+		}
+	}
+
+	// global indexes are deleted before others are created, so
+	// that a changed index is recreated, and before billing is
+	// switched, which would otherwise need throughput for them
+	for _, index := range desc.GlobalSecondaryIndexes {
+		if want := findGlobal(spec.GlobalIndexes, index.IndexName); want == nil || !sameIndex(index.Index, want.Index) {
+			steps = append(steps, updateTable(name, "delete index "+index.IndexName, &dynamodb.TableUpdate{
+				GlobalSecondaryIndexUpdates: []dynamodb.GlobalIndexUpdate{dynamodb.DeleteIndex(index.IndexName)},
+			}))
+		}
+	}
+
+	// billing and throughput of the table
+	billing := desc.BillingMode()
+	wantBilling := spec.BillingMode
+	if wantBilling == "" {
+		wantBilling = dynamodb.BillingProvisioned
+	}
+	throughput := dynamodb.ProvisionedThroughput{ReadCapacityUnits: spec.ReadCapacity, WriteCapacityUnits: spec.WriteCapacity}
+	switch {
+	case billing != wantBilling:
+		update := &dynamodb.TableUpdate{BillingMode: wantBilling}
+		action := "switch billing to " + wantBilling
+		if spec.Provisioned() {
+			update.ProvisionedThroughput = &throughput
+			action += " at " + capacity(throughput)
+
+			// the global indexes which are kept need throughput too
+			for _, index := range spec.GlobalIndexes {
+				if found := findGlobalIndex(desc, index.IndexName); found != nil && sameIndex(*found, index.Index) {
+					update.GlobalSecondaryIndexUpdates = append(update.GlobalSecondaryIndexUpdates,
+						dynamodb.UpdateIndex(index.IndexName, index.ProvisionedThroughput))
+				}
+			}
+		}
+		steps = append(steps, updateTable(name, action, update))
+	case spec.Provisioned() && desc.ProvisionedThroughput.ProvisionedThroughput != throughput:
+		steps = append(steps, updateTable(name,
+			fmt.Sprintf("change throughput from %s to %s", capacity(desc.ProvisionedThroughput.ProvisionedThroughput), capacity(throughput)),
+			&dynamodb.TableUpdate{ProvisionedThroughput: &throughput},
+		))
+	}
+
+	// the keys of the table and of the indexes which are kept,
+	// whose attributes are defined along with those of each
+	// index created
+	keys := [][]dynamodb.KeyItem{spec.KeySchema}
+	for _, index := range spec.LocalIndexes {
+		keys = append(keys, index.KeySchema)
+	}
+	for _, index := range spec.GlobalIndexes {
+		if found := findGlobalIndex(desc, index.IndexName); found != nil && sameIndex(*found, index.Index) {
+			keys = append(keys, index.KeySchema)
+		}
+	}
+	for _, index := range spec.GlobalIndexes {
+		found := findGlobalIndex(desc, index.IndexName)
+		if found == nil || !sameIndex(*found, index.Index) {
+			if !spec.Provisioned() {
+				index.ProvisionedThroughput = dynamodb.ProvisionedThroughput{}
+			}
+			used := append(keys[:len(keys):len(keys)], index.KeySchema)
+			steps = append(steps, updateTable(name, "create index "+index.IndexName, &dynamodb.TableUpdate{
+				AttributeDefinitions:        definitions(spec.AttributeDefinitions, used),
+				GlobalSecondaryIndexUpdates: []dynamodb.GlobalIndexUpdate{dynamodb.CreateIndex(index)},
+			}))
+			continue
+		}
+		current := globalThroughput(desc, index.IndexName)
+		if billing == wantBilling && spec.Provisioned() && current != index.ProvisionedThroughput {
+			steps = append(steps, updateTable(name,
+				fmt.Sprintf("change throughput of index %s from %s to %s", index.IndexName, capacity(current), capacity(index.ProvisionedThroughput)),
+				&dynamodb.TableUpdate{GlobalSecondaryIndexUpdates: []dynamodb.GlobalIndexUpdate{
+					dynamodb.UpdateIndex(index.IndexName, index.ProvisionedThroughput),
+				}},
+			))
+		}
+	}
+
+	// a stream is disabled before its view type can change
+	var stream dynamodb.StreamSpecification
+	if desc.StreamSpecification != nil {
+		stream = *desc.StreamSpecification
+	}
+	if want := spec.Stream; want != nil && (want.StreamEnabled != stream.StreamEnabled || want.StreamEnabled && want.StreamViewType != stream.StreamViewType) {
+		if stream.StreamEnabled {
+			steps = append(steps, updateTable(name, "disable stream", &dynamodb.TableUpdate{
+				StreamSpecification: &dynamodb.StreamSpecification{StreamEnabled: false},
+			}))
+		}
+		if want.StreamEnabled {
+			steps = append(steps, updateTable(name, "enable stream of "+want.StreamViewType, &dynamodb.TableUpdate{
+				StreamSpecification: want,
+			}))
+		}
+	}
+
+	// likewise time to live before its attribute can change
+	if want := spec.timeToLive(); want != nil && (want.Enabled != current.ttl.Enabled || want.Enabled && want.AttributeName != current.ttl.AttributeName) {
+		if current.ttl.Enabled {
+			steps = append(steps, updateTimeToLive(name, dynamodb.TimeToLiveSpecification{Enabled: false, AttributeName: current.ttl.AttributeName}))
+		}
+		if want.Enabled {
+			steps = append(steps, updateTimeToLive(name, *want))
+		}
+	}
+
+	// tags are added or changed but others are kept
+	var tags []dynamodb.Tag
+	var changed []string
+next:
+	for _, tag := range spec.Tags {
+		for _, have := range current.tags {
+			if have == tag {
+				continue next
+			}
+		}
+		tags = append(tags, tag)
+		changed = append(changed, tag.Key+"="+tag.Value)
+	}
+	if len(tags) > 0 {
+		arn := desc.TableArn
+		steps = append(steps, &Step{
+			Table:  name,
+			Action: "tag " + strings.Join(changed, ", "),
+			apply: func(ctx context.Context, c *dynamodb.Client) error {
+				return c.TagResource(ctx, arn, tags)
+			},
+		})
+	}
+	return steps, nil
+}
+
+// updateTable creates a Step sending update.
+func updateTable(name, action string, update *dynamodb.TableUpdate) *Step {
+	return &Step{
+		Table:  name,
+		Action: action,
+		apply: func(ctx context.Context, c *dynamodb.Client) error {
+			_, err := c.UpdateTableWith(ctx, name, update)
+			return err
+		},
+		wait: true,
+	}
+}
+
+// updateTimeToLive creates a Step configuring time to live.
+func updateTimeToLive(name string, ttl dynamodb.TimeToLiveSpecification) *Step {
+	action := "enable time to live on " + ttl.AttributeName
+	if !ttl.Enabled {
+		action = "disable time to live on " + ttl.AttributeName
+	}
+	return &Step{
+		Table:  name,
+		Action: action,
+		apply: func(ctx context.Context, c *dynamodb.Client) error {
+			_, err := c.UpdateTimeToLive(ctx, name, ttl)
+			return err
+		},
+	}
+}
+
+// definitions returns those of defs which are used by keys, as
+// DynamoDB rejects the definitions of attributes which aren't.
+func definitions(defs []dynamodb.AttributeDefinition, keys [][]dynamodb.KeyItem) []dynamodb.AttributeDefinition {
+	var used []dynamodb.AttributeDefinition
+next:
+	for _, def := range defs {
+		for _, key := range keys {
+			for _, item := range key {
+				if item.AttributeName == def.AttributeName {
+					used = append(used, def)
+					continue next
+				}
+			}
+		}
+	}
+	return used
+}
+
+func capacity(t dynamodb.ProvisionedThroughput) string {
+	return fmt.Sprintf("%d/%d", t.ReadCapacityUnits, t.WriteCapacityUnits)
+}
+
+// sameKeys reports whether two key schemas are equal, whatever
+// the order of their attributes.
+func sameKeys(a, b []dynamodb.KeyItem) bool {
+	if len(a) != len(b) {
+		return false
+	}
+next:
+	for _, key := range a {
+		for _, other := range b {
+			if key == other {
+				continue next
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// sameIndex reports whether two indexes have the same keys and
+// projection.
+func sameIndex(a, b dynamodb.Index) bool {
+	if !sameKeys(a.KeySchema, b.KeySchema) || a.Projection.ProjectionType != b.Projection.ProjectionType {
+		return false
+	}
+	attrs := append([]string(nil), a.Projection.NonKeyAttributes...)
+	other := append([]string(nil), b.Projection.NonKeyAttributes...)
+	if len(attrs) != len(other) {
+		return false
+	}
+	sort.Strings(attrs)
+	sort.Strings(other)
+	for i := range attrs {
+		if attrs[i] != other[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func findIndex(indexes []dynamodb.Index, name string) *dynamodb.Index {
+	for i := range indexes {
+		if indexes[i].IndexName == name {
+			return &indexes[i]
+		}
+	}
+	return nil
+}
+
+func findGlobal(indexes []dynamodb.GlobalIndex, name string) *dynamodb.GlobalIndex {
+	for i := range indexes {
+		if indexes[i].IndexName == name {
+			return &indexes[i]
+		}
+	}
+	return nil
+}
+
+// findGlobalIndex finds a global index of the described table.
+func findGlobalIndex(desc *dynamodb.TableDesc, name string) *dynamodb.Index {
+	for i := range desc.GlobalSecondaryIndexes {
+		if desc.GlobalSecondaryIndexes[i].IndexName == name {
+			return &desc.GlobalSecondaryIndexes[i].Index
+		}
+	}
+	return nil
+}
+
+// globalThroughput returns the throughput of a global index of
+// the described table.
+func globalThroughput(desc *dynamodb.TableDesc, name string) dynamodb.ProvisionedThroughput {
+	for _, index := range desc.GlobalSecondaryIndexes {
+		if index.IndexName == name {
+			return index.ProvisionedThroughput.ProvisionedThroughput
+		}
+	}
+	return dynamodb.ProvisionedThroughput{}
+}
@@ -0,0 +1,221 @@
+package migrate
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/groupme/dynamodb-1"
+	"golang.org/x/net/context"
+)
+
+type user struct {
+	ID    string `ddb:"id,HASH"`
+	Email string `ddb:"email,gsi=ByEmail:HASH"`
+	Team  string `ddb:"team,gsi=ByTeam:HASH"`
+}
+
+func newSpec(t *testing.T, opts *dynamodb.TableOptions) *Spec {
+	spec, err := NewSpec("users", &user{}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return spec
+}
+
+// described describes the table as created from spec.
+func described(spec *Spec) *state {
+	s := &state{}
+	s.desc.BillingModeSummary = &dynamodb.BillingModeSummary{BillingMode: spec.BillingMode}
+	s.desc.TableName = spec.TableName
+	s.desc.KeySchema = spec.KeySchema
+	s.desc.ProvisionedThroughput.ReadCapacityUnits = spec.ReadCapacity
+	s.desc.ProvisionedThroughput.WriteCapacityUnits = spec.WriteCapacity
+	data, _ := json.Marshal(spec.GlobalIndexes)
+	json.Unmarshal(data, &s.desc.GlobalSecondaryIndexes)
+	for i, index := range spec.GlobalIndexes {
+		s.desc.GlobalSecondaryIndexes[i].ProvisionedThroughput.ProvisionedThroughput = index.ProvisionedThroughput
+	}
+	s.desc.StreamSpecification = spec.Stream
+	if spec.TimeToLive != nil {
+		s.ttl = *spec.TimeToLive
+	}
+	s.tags = spec.Tags
+	return s
+}
+
+func actions(steps []*Step) []string {
+	var actions []string
+	for _, step := range steps {
+		actions = append(actions, step.Action)
+	}
+	return actions
+}
+
+func TestPlan(t *testing.T) {
+	current := newSpec(t, &dynamodb.TableOptions{ReadCapacity: 1, WriteCapacity: 1})
+	current.Stream = &dynamodb.StreamSpecification{StreamEnabled: true, StreamViewType: dynamodb.StreamKeysOnly}
+	current.TimeToLive = &dynamodb.TimeToLiveSpecification{Enabled: true, AttributeName: "expires"}
+	current.Tags = []dynamodb.Tag{{Key: "env", Value: "prod"}, {Key: "team", Value: "core"}}
+
+	cases := []struct {
+		change func(spec *Spec)
+		want   []string
+	}{
+		{func(spec *Spec) {}, nil},
+		{func(spec *Spec) {
+			spec.ReadCapacity = 5
+		}, []string{"change throughput from 1/1 to 5/1"}},
+		{func(spec *Spec) {
+			spec.BillingMode = dynamodb.BillingPayPerRequest
+		}, []string{"switch billing to PAY_PER_REQUEST"}},
+		{func(spec *Spec) {
+			spec.GlobalIndexes[0].Projection.ProjectionType = dynamodb.ProjectKeysOnly
+			spec.GlobalIndexes[1].ProvisionedThroughput.WriteCapacityUnits = 3
+			spec.GlobalIndexes = append(spec.GlobalIndexes, dynamodb.GlobalIndex{Index: dynamodb.Index{IndexName: "ByName"}})
+		}, []string{
+			"delete index ByEmail",
+			"create index ByEmail",
+			"change throughput of index ByTeam from 1/1 to 1/3",
+			"create index ByName",
+		}},
+		{func(spec *Spec) {
+			spec.BillingMode = dynamodb.BillingPayPerRequest
+			spec.GlobalIndexes[0].Projection.ProjectionType = dynamodb.ProjectKeysOnly
+		}, []string{
+			"delete index ByEmail",
+			"switch billing to PAY_PER_REQUEST",
+			"create index ByEmail",
+		}},
+		{func(spec *Spec) {
+			spec.GlobalIndexes = spec.GlobalIndexes[1:]
+			spec.Stream = &dynamodb.StreamSpecification{StreamEnabled: true, StreamViewType: dynamodb.StreamNewImage}
+			spec.TimeToLive = &dynamodb.TimeToLiveSpecification{}
+		}, []string{
+			"delete index ByEmail",
+			"disable stream",
+			"enable stream of NEW_IMAGE",
+			"disable time to live on expires",
+		}},
+		{func(spec *Spec) {
+			spec.Stream = nil
+			spec.TimeToLive = nil
+			spec.Tags = nil
+		}, nil},
+		{func(spec *Spec) {
+			spec.Tags = []dynamodb.Tag{{Key: "env", Value: "staging"}, {Key: "team", Value: "core"}, {Key: "cost", Value: "42"}}
+		}, []string{"tag env=staging, cost=42"}},
+	}
+	for i, c := range cases {
+		spec := newSpec(t, &dynamodb.TableOptions{ReadCapacity: 1, WriteCapacity: 1})
+		spec.Stream = current.Stream
+		spec.TimeToLive = current.TimeToLive
+		spec.Tags = current.Tags
+		c.change(spec)
+		steps, err := plan(spec, described(current))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := actions(steps); !reflect.DeepEqual(got, c.want) {
+			t.Error("case", i)
+			t.Error("want", c.want)
+			t.Error("got ", got)
+		}
+	}
+
+	// the key can't change
+	spec := newSpec(t, &dynamodb.TableOptions{ReadCapacity: 1, WriteCapacity: 1})
+	spec.KeySchema = []dynamodb.KeyItem{{AttributeName: "email", KeyType: "HASH"}}
+	if _, err := plan(spec, described(current)); err == nil {
+		t.Error("want error for changed key")
+	}
+
+	// provisioned tables need their capacity, rather than
+	// changing it to 0/0
+	spec = newSpec(t, nil)
+	if _, err := plan(spec, described(current)); err == nil {
+		t.Error("want error for unset capacity")
+	}
+	spec.ReadCapacity, spec.WriteCapacity = 1, 1
+	if steps, err := plan(spec, described(current)); err != nil || len(steps) != 0 {
+		t.Error("got", actions(steps), err)
+	}
+
+	// missing tables are created
+	steps, _ := plan(current, nil)
+	if got := actions(steps); !reflect.DeepEqual(got, []string{"create table", "enable time to live on expires"}) {
+		t.Error("got", got)
+	}
+}
+
+func TestApply(t *testing.T) {
+	var targets []string
+	var updates []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target := r.Header.Get("X-Amz-Target")[len("DynamoDB_20120810."):]
+		targets = append(targets, target)
+		body, _ := ioutil.ReadAll(r.Body)
+		switch target {
+		case "DescribeTable":
+			w.Write([]byte(`{"Table":{
+				"TableName":"users",
+				"TableStatus":"ACTIVE",
+				"KeySchema":[{"AttributeName":"id","KeyType":"HASH"}],
+				"BillingModeSummary":{"BillingMode":"PAY_PER_REQUEST"}
+			}}`))
+		case "DescribeTimeToLive":
+			w.Write([]byte(`{"TimeToLiveDescription":{"TimeToLiveStatus":"DISABLED"}}`))
+		case "UpdateTable":
+			var update map[string]interface{}
+			json.Unmarshal(body, &update)
+			updates = append(updates, update)
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+	client := dynamodb.Dial(dynamodb.EndPoint("Test", "local", u.Host, false), dynamodb.Auth("key", "secret"), nil)
+
+	ctx := context.Background()
+	spec := newSpec(t, &dynamodb.TableOptions{BillingMode: dynamodb.BillingPayPerRequest})
+	steps, err := Plan(ctx, client, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := actions(steps); !reflect.DeepEqual(got, []string{"create index ByEmail", "create index ByTeam"}) {
+		t.Fatal("got", got)
+	}
+	targets = nil
+	var reported []string
+	if err := Apply(ctx, client, steps, func(step *Step) { reported = append(reported, step.String()) }); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(targets, []string{"UpdateTable", "DescribeTable", "UpdateTable", "DescribeTable"}) || reported[1] != "users: create index ByTeam" {
+		t.Error("got", targets, reported)
+	}
+	want := map[string]interface{}{
+		"IndexName":  "ByTeam",
+		"KeySchema":  []interface{}{map[string]interface{}{"AttributeName": "team", "KeyType": "HASH"}},
+		"Projection": map[string]interface{}{"NonKeyAttributes": nil, "ProjectionType": "ALL"},
+	}
+	if got := updates[1]["GlobalSecondaryIndexUpdates"].([]interface{})[0].(map[string]interface{})["Create"]; !reflect.DeepEqual(got, want) {
+		t.Error("want", want)
+		t.Error("got ", got)
+	}
+
+	// each step only defines the attributes of the keys in use
+	for i, attr := range []string{"email", "team"} {
+		want := []interface{}{
+			map[string]interface{}{"AttributeName": "id", "AttributeType": "S"},
+			map[string]interface{}{"AttributeName": attr, "AttributeType": "S"},
+		}
+		if got := updates[i]["AttributeDefinitions"]; !reflect.DeepEqual(got, want) {
+			t.Error("want", want)
+			t.Error("got ", got)
+		}
+	}
+}
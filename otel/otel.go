@@ -0,0 +1,71 @@
+// Public Domain (-) 2012-2013 The Go DynamoDB Authors.
+// See the Go DynamoDB UNLICENSE file for details.
+
+// Package dynamodbotel adapts OpenTelemetry tracers to the
+// dynamodb.Tracer interface, e.g.
+//
+//     client.Tracer = dynamodbotel.New(provider.Tracer("dynamodb"))
+//
+// The OpenTelemetry API it depends on is vendored in Godeps
+// and takes the context package of the standard library, to
+// which the golang.org/x/net/context contexts of the dynamodb
+// package convert.
+package dynamodbotel
+
+import (
+	"fmt"
+
+	"github.com/groupme/dynamodb-1"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+)
+
+// Tracer satisfies the dynamodb.Tracer interface by starting
+// client spans with an OpenTelemetry tracer.
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// New creates a Tracer which starts spans with tracer.
+func New(tracer trace.Tracer) *Tracer {
+	return &Tracer{tracer: tracer}
+}
+
+// StartSpan satisfies the dynamodb.Tracer interface.
+func (t *Tracer) StartSpan(ctx context.Context, name string) (context.Context, dynamodb.Span) {
+	ctx, s := t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+	return ctx, span{s}
+}
+
+type span struct {
+	span trace.Span
+}
+
+func (s span) SetAttribute(key string, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		s.span.SetAttributes(attribute.String(key, v))
+	case []string:
+		s.span.SetAttributes(attribute.StringSlice(key, v))
+	case int:
+		s.span.SetAttributes(attribute.Int(key, v))
+	case int64:
+		s.span.SetAttributes(attribute.Int64(key, v))
+	case float64:
+		s.span.SetAttributes(attribute.Float64(key, v))
+	case bool:
+		s.span.SetAttributes(attribute.Bool(key, v))
+	default:
+		s.span.SetAttributes(attribute.String(key, fmt.Sprint(v)))
+	}
+}
+
+func (s span) End(err error) {
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
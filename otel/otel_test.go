@@ -0,0 +1,114 @@
+package dynamodbotel
+
+import (
+	stdcontext "context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/groupme/dynamodb-1"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+)
+
+// recorder is a trace.Tracer keeping the spans it starts. The
+// embedded interfaces are nil, only the methods the adapter
+// uses are implemented. Start takes the standard library's
+// context like the trace.Tracer interface.
+type recorder struct {
+	trace.Tracer
+	spans []*recordedSpan
+}
+
+func (r *recorder) Start(ctx stdcontext.Context, name string, opts ...trace.SpanStartOption) (stdcontext.Context, trace.Span) {
+	config := trace.NewSpanStartConfig(opts...)
+	s := &recordedSpan{
+		name:  name,
+		kind:  config.SpanKind(),
+		attrs: map[attribute.Key]attribute.Value{},
+	}
+	r.spans = append(r.spans, s)
+	return ctx, s
+}
+
+type recordedSpan struct {
+	trace.Span
+	name   string
+	kind   trace.SpanKind
+	attrs  map[attribute.Key]attribute.Value
+	errs   []error
+	status codes.Code
+	ended  bool
+}
+
+func (s *recordedSpan) SetAttributes(kv ...attribute.KeyValue) {
+	for _, a := range kv {
+		s.attrs[a.Key] = a.Value
+	}
+}
+
+func (s *recordedSpan) RecordError(err error, opts ...trace.EventOption) {
+	s.errs = append(s.errs, err)
+}
+
+func (s *recordedSpan) SetStatus(code codes.Code, description string) {
+	s.status = code
+}
+
+func (s *recordedSpan) End(opts ...trace.SpanEndOption) {
+	s.ended = true
+}
+
+func TestTracer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Target") == "DynamoDB_20120810.DeleteTable" {
+			w.WriteHeader(400)
+			w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#ResourceNotFoundException","message":"not found"}`))
+			return
+		}
+		w.Write([]byte(`{"Table":{"TableName":"Users","TableStatus":"ACTIVE"}}`))
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+	client := dynamodb.Dial(dynamodb.EndPoint("Test", "local", u.Host, false), dynamodb.Auth("key", "secret"), nil)
+	client.ErrorLog = nil
+	rec := &recorder{}
+	client.Tracer = New(rec)
+
+	ctx := context.Background()
+	client.Call(ctx, "DescribeTable", dynamodb.Map{"TableName": "Users"})
+	client.Call(ctx, "DeleteTable", dynamodb.Map{"TableName": "Users"})
+	if len(rec.spans) != 2 {
+		t.Fatal("got", len(rec.spans), "spans")
+	}
+
+	s := rec.spans[0]
+	if s.name != "DynamoDB.DescribeTable" || s.kind != trace.SpanKindClient || !s.ended || s.status != codes.Unset {
+		t.Error("got", s.name, s.kind, s.ended, s.status)
+	}
+	want := map[attribute.Key]attribute.Value{
+		dynamodb.AttrSystem:     attribute.StringValue("aws-api"),
+		dynamodb.AttrService:    attribute.StringValue("DynamoDB"),
+		dynamodb.AttrMethod:     attribute.StringValue("DescribeTable"),
+		dynamodb.AttrTableNames: attribute.StringSliceValue([]string{"Users"}),
+		dynamodb.AttrAttempts:   attribute.IntValue(1),
+		dynamodb.AttrThrottles:  attribute.IntValue(0),
+	}
+	if !reflect.DeepEqual(s.attrs, want) {
+		t.Error("want", want)
+		t.Error("got ", s.attrs)
+	}
+
+	s = rec.spans[1]
+	if !s.ended || s.status != codes.Error || len(s.errs) != 1 {
+		t.Error("got", s.ended, s.status, s.errs)
+	}
+	if got := s.attrs[dynamodb.AttrErrorType].AsString(); got != "ResourceNotFoundException" {
+		t.Error("want", "ResourceNotFoundException")
+		t.Error("got ", got)
+	}
+}
@@ -0,0 +1,335 @@
+// Public Domain (-) 2012-2013 The Go DynamoDB Authors.
+// See the Go DynamoDB UNLICENSE file for details.
+
+package dynamodb
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// RateLimiter throttles requests on the client side using a
+// token bucket per table. The rate of each bucket, measured
+// in capacity units per second, adapts to DynamoDB's
+// feedback: it is decreased multiplicatively whenever a
+// request is throttled and increased additively as requests
+// succeed (AIMD). A single RateLimiter is meant to be shared
+// by all the goroutines using a Client, so that they back off
+// together instead of independently, e.g.
+//
+//     client := dynamodb.Dial(endpoint, auth, nil)
+//     client.Limiter = dynamodb.NewRateLimiter(100)
+//
+// The zero value is usable too: its buckets start at Min.
+//
+// Every request made through the Client waits for a token,
+// including those made with Call for batch operations. When
+// the response reports the capacity consumed, the remaining
+// units are charged against the bucket once the request
+// completes.
+type RateLimiter struct {
+	// Min and Max bound the rate of each bucket. Min defaults
+	// to DefaultRateMin and a zero Max leaves the rate
+	// unbounded.
+	Min float64
+	Max float64
+
+	// Increase is the number of units per second added to the
+	// rate for every second's worth of successful requests. It
+	// defaults to DefaultRateIncrease.
+	Increase float64
+
+	// Decrease is the factor the rate is multiplied by when a
+	// request is throttled. It defaults to DefaultRateDecrease.
+	Decrease float64
+
+	rate    float64
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+// Defaults used by NewRateLimiter.
+const (
+	DefaultRateMin      = 1
+	DefaultRateIncrease = 1
+	DefaultRateDecrease = 0.5
+)
+
+// decreaseInterval stops a burst of throttled responses for
+// requests sent at the same time from collapsing the rate more
+// than once.
+const decreaseInterval = 500 * time.Millisecond
+
+// NewRateLimiter creates a RateLimiter whose buckets start at
+// rate capacity units per second. The rate may grow up to 10
+// times the initial value. Rates below DefaultRateMin, including
+// zero and negative ones, are raised to it.
+func NewRateLimiter(rate float64) *RateLimiter {
+	if !(rate >= DefaultRateMin) {
+		rate = DefaultRateMin
+	}
+	return &RateLimiter{
+		Min:      DefaultRateMin,
+		Max:      rate * 10,
+		Increase: DefaultRateIncrease,
+		Decrease: DefaultRateDecrease,
+		rate:     rate,
+		buckets:  map[string]*bucket{},
+		now:      time.Now,
+	}
+}
+
+type bucket struct {
+	rate         float64
+	tokens       float64
+	last         time.Time
+	lastDecrease time.Time
+}
+
+// refill adds the tokens accumulated since the last refill,
+// allowing at most one second's worth of burst.
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * b.rate
+		if b.tokens > b.rate {
+			b.tokens = b.rate
+		}
+	}
+	b.last = now
+}
+
+// bucket must be called with l.mu held.
+func (l *RateLimiter) bucket(table string) *bucket {
+	b, ok := l.buckets[table]
+	if !ok {
+		rate := l.rate
+		if rate < l.min() {
+			rate = l.min()
+		}
+		b = &bucket{
+			rate:   rate,
+			tokens: rate,
+			last:   l.clock(),
+		}
+		if l.buckets == nil {
+			l.buckets = map[string]*bucket{}
+		}
+		l.buckets[table] = b
+	}
+	return b
+}
+
+func (l *RateLimiter) clock() time.Time {
+	if l.now == nil {
+		return time.Now()
+	}
+	return l.now()
+}
+
+func (l *RateLimiter) min() float64 {
+	if l.Min <= 0 {
+		return DefaultRateMin
+	}
+	return l.Min
+}
+
+// Rate returns the current rate of the bucket for table.
+func (l *RateLimiter) Rate(table string) float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.bucket(table).rate
+}
+
+// Wait blocks until n units are available for table or the
+// context is done.
+func (l *RateLimiter) Wait(ctx context.Context, table string, n float64) error {
+	l.mu.Lock()
+	b := l.bucket(table)
+	b.refill(l.clock())
+	b.tokens -= n
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-ctx.Done():
+		// hand back the reservation we won't be using
+		l.mu.Lock()
+		b.tokens += n
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Throttled decreases the rate for table after DynamoDB
+// rejected a request for exceeding its throughput.
+func (l *RateLimiter) Throttled(table string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.clock()
+	b := l.bucket(table)
+	b.refill(now)
+	if now.Sub(b.lastDecrease) < decreaseInterval {
+		return
+	}
+	b.lastDecrease = now
+	decrease := l.Decrease
+	if decrease <= 0 {
+		decrease = DefaultRateDecrease
+	}
+	b.rate *= decrease
+	if b.rate < l.min() {
+		b.rate = l.min()
+	}
+	if b.tokens > 0 {
+		b.tokens = 0
+	}
+}
+
+// Consumed charges the units a request consumed beyond the one
+// it waited for and increases the rate for table.
+func (l *RateLimiter) Consumed(table string, units float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b := l.bucket(table)
+	if units > 1 {
+		b.tokens -= units - 1
+	} else {
+		units = 1
+	}
+	increase := l.Increase
+	if increase <= 0 {
+		increase = DefaultRateIncrease
+	}
+	b.rate += increase * units / b.rate
+	if l.Max > 0 && b.rate > l.Max {
+		b.rate = l.Max
+	}
+}
+
+func (l *RateLimiter) wait(ctx context.Context, tables []string) error {
+	for _, table := range tables {
+		if err := l.Wait(ctx, table, 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *RateLimiter) throttled(tables []string) {
+	for _, table := range tables {
+		l.Throttled(table)
+	}
+}
+
+func (l *RateLimiter) consumed(tables []string, capacity []ConsumedCapacity) {
+	for _, table := range tables {
+		units := 0.0
+		for _, cc := range capacity {
+			if cc.TableName == table {
+				units += cc.CapacityUnits
+			}
+		}
+		l.Consumed(table, units)
+	}
+}
+
+// requestTables finds the tables a raw request operates on,
+// either from its TableName or the keys of its RequestItems in
+// the case of batch operations.
+func requestTables(payload []byte) []string {
+	var req struct {
+		TableName    string
+		RequestItems map[string]json.RawMessage
+	}
+	if json.Unmarshal(payload, &req) != nil {
+		return nil
+	}
+	if req.TableName != "" {
+		return []string{req.TableName}
+	}
+	tables := make([]string, 0, len(req.RequestItems))
+	for name := range req.RequestItems {
+		tables = append(tables, name)
+	}
+	sort.Strings(tables)
+	return tables
+}
+
+// Operations whose response can report the capacity they
+// consumed.
+var capacityOps = map[string]bool{
+	"BatchGetItem":       true,
+	"BatchWriteItem":     true,
+	"DeleteItem":         true,
+	"GetItem":            true,
+	"PutItem":            true,
+	"Query":              true,
+	"Scan":               true,
+	"TransactGetItems":   true,
+	"TransactWriteItems": true,
+	"UpdateItem":         true,
+}
+
+// withConsumedCapacity asks DynamoDB to report the total
+// capacity consumed by the request unless the caller already
+// chose what should be returned.
+func withConsumedCapacity(method string, payload []byte) []byte {
+	if !capacityOps[method] || len(payload) < 2 || payload[0] != '{' {
+		return payload
+	}
+	if bytes.Contains(payload, []byte(`"ReturnConsumedCapacity"`)) {
+		return payload
+	}
+	rest := bytes.TrimSpace(payload[1:])
+	if len(rest) == 0 {
+		// not an object, let DynamoDB reject it
+		return payload
+	}
+	field := `"ReturnConsumedCapacity":"TOTAL"`
+	if rest[0] != '}' {
+		field += ","
+	}
+	injected := make([]byte, 0, len(payload)+len(field))
+	injected = append(injected, '{')
+	injected = append(injected, field...)
+	return append(injected, payload[1:]...)
+}
+
+// consumedCapacity parses the ConsumedCapacity reported in a
+// response, which is a single object for item operations and
+// a list for batch operations.
+func consumedCapacity(body []byte) []ConsumedCapacity {
+	if !bytes.Contains(body, []byte(`"ConsumedCapacity"`)) {
+		return nil
+	}
+	var resp struct {
+		ConsumedCapacity json.RawMessage
+	}
+	if json.Unmarshal(body, &resp) != nil || len(resp.ConsumedCapacity) == 0 {
+		return nil
+	}
+	if resp.ConsumedCapacity[0] == '[' {
+		var list []ConsumedCapacity
+		json.Unmarshal(resp.ConsumedCapacity, &list)
+		return list
+	}
+	var cc ConsumedCapacity
+	if json.Unmarshal(resp.ConsumedCapacity, &cc) != nil {
+		return nil
+	}
+	return []ConsumedCapacity{cc}
+}
@@ -0,0 +1,162 @@
+package dynamodb
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestRateLimiterAIMD(t *testing.T) {
+	now := time.Unix(0, 0)
+	limiter := NewRateLimiter(10)
+	limiter.now = func() time.Time { return now }
+
+	limiter.Throttled("Test")
+	if rate := limiter.Rate("Test"); rate != 5 {
+		t.Error("want", 5)
+		t.Error("got ", rate)
+	}
+
+	// a burst of throttles only decreases the rate once
+	limiter.Throttled("Test")
+	if rate := limiter.Rate("Test"); rate != 5 {
+		t.Error("want", 5)
+		t.Error("got ", rate)
+	}
+
+	now = now.Add(time.Second)
+	limiter.Throttled("Test")
+	if rate := limiter.Rate("Test"); rate != 2.5 {
+		t.Error("want", 2.5)
+		t.Error("got ", rate)
+	}
+
+	limiter.Consumed("Test", 5)
+	if rate := limiter.Rate("Test"); rate != 4.5 {
+		t.Error("want", 4.5)
+		t.Error("got ", rate)
+	}
+
+	// other tables are unaffected
+	if rate := limiter.Rate("Other"); rate != 10 {
+		t.Error("want", 10)
+		t.Error("got ", rate)
+	}
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	limiter := NewRateLimiter(1)
+	ctx := context.Background()
+	if err := limiter.Wait(ctx, "Test", 1); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx, "Test", 1); err != context.DeadlineExceeded {
+		t.Error("want", context.DeadlineExceeded)
+		t.Error("got ", err)
+	}
+}
+
+func TestRateLimiterMin(t *testing.T) {
+	for _, rate := range []float64{0, -1} {
+		limiter := NewRateLimiter(rate)
+		if got := limiter.Rate("Test"); got != DefaultRateMin || limiter.Max <= 0 {
+			t.Error("want", DefaultRateMin)
+			t.Error("got ", got, limiter.Max)
+		}
+	}
+}
+
+func TestRateLimiterZero(t *testing.T) {
+	limiter := &RateLimiter{Max: 100}
+	if err := limiter.Wait(context.Background(), "Test", 1); err != nil {
+		t.Fatal(err)
+	}
+	if rate := limiter.Rate("Test"); rate != DefaultRateMin {
+		t.Error("want", DefaultRateMin)
+		t.Error("got ", rate)
+	}
+	limiter.Consumed("Test", 1)
+	if rate := limiter.Rate("Test"); rate != DefaultRateMin+DefaultRateIncrease {
+		t.Error("want", DefaultRateMin+DefaultRateIncrease)
+		t.Error("got ", rate)
+	}
+	limiter.Throttled("Test")
+	if rate := limiter.Rate("Test"); rate != (DefaultRateMin+DefaultRateIncrease)*DefaultRateDecrease {
+		t.Error("want", (DefaultRateMin+DefaultRateIncrease)*DefaultRateDecrease)
+		t.Error("got ", rate)
+	}
+
+	// and so is a client using it
+	server, client := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	})
+	defer server.Close()
+	client.Limiter = &RateLimiter{}
+	if _, err := client.Call(context.Background(), "PutItem", Map{"TableName": "Test"}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRequestTables(t *testing.T) {
+	tables := requestTables([]byte(`{"TableName":"Test","Key":{}}`))
+	if !reflect.DeepEqual(tables, []string{"Test"}) {
+		t.Error("got", tables)
+	}
+	tables = requestTables([]byte(`{"RequestItems":{"B":[],"A":[]}}`))
+	if !reflect.DeepEqual(tables, []string{"A", "B"}) {
+		t.Error("got", tables)
+	}
+}
+
+func TestWithConsumedCapacity(t *testing.T) {
+	cases := map[string]string{
+		`{}`:                                `{"ReturnConsumedCapacity":"TOTAL"}`,
+		`{"TableName":"Test"}`:              `{"ReturnConsumedCapacity":"TOTAL","TableName":"Test"}`,
+		`{"ReturnConsumedCapacity":"NONE"}`: `{"ReturnConsumedCapacity":"NONE"}`,
+		`{ `:                                `{ `,
+	}
+	for in, want := range cases {
+		if got := string(withConsumedCapacity("PutItem", []byte(in))); got != want {
+			t.Error("want", want)
+			t.Error("got ", got)
+		}
+	}
+	if got := string(withConsumedCapacity("DescribeTable", []byte(`{}`))); got != `{}` {
+		t.Error("got", got)
+	}
+}
+
+func TestClientLimiter(t *testing.T) {
+	calls := 0
+	server, client := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(400)
+			w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#ProvisionedThroughputExceededException","message":"slow down"}`))
+			return
+		}
+		w.Write([]byte(`{"ConsumedCapacity":{"TableName":"Test","CapacityUnits":2}}`))
+	})
+	defer server.Close()
+
+	client.ErrorLog = nil
+	client.Limiter = NewRateLimiter(100)
+
+	err := client.Table("Test").Put(context.Background(), &MyItem{Name: "Tom"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Error("want", 2)
+		t.Error("got ", calls)
+	}
+	if rate := client.Limiter.Rate("Test"); rate != 50.04 {
+		t.Error("want", 50.04)
+		t.Error("got ", rate)
+	}
+}
@@ -0,0 +1,327 @@
+// Public Domain (-) 2012-2013 The Go DynamoDB Authors.
+// See the Go DynamoDB UNLICENSE file for details.
+
+package dynamodb
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// registry holds the Tables of a Client, so that every call to
+// Client.Table with the same name shares the schema cached by
+// the first.
+type registry struct {
+	mu     sync.RWMutex
+	tables map[string]*Table
+}
+
+// Table gets or initializes the Table called name. Tables are
+// safe for concurrent use.
+func (c *Client) Table(name string) *Table {
+	c.registry.mu.RLock()
+	t := c.registry.tables[name]
+	c.registry.mu.RUnlock()
+	if t != nil {
+		return t
+	}
+
+	c.registry.mu.Lock()
+	defer c.registry.mu.Unlock()
+	if t = c.registry.tables[name]; t == nil {
+		if c.registry.tables == nil {
+			c.registry.tables = map[string]*Table{}
+		}
+		t = newTable(c, name)
+		c.registry.tables[name] = t
+	}
+	return t
+}
+
+// lookup returns the Table called name if it has been
+// initialized, or nil.
+func (c *Client) lookup(name string) *Table {
+	c.registry.mu.RLock()
+	defer c.registry.mu.RUnlock()
+	return c.registry.tables[name]
+}
+
+// keyAttr is an attribute of a key schema.
+type keyAttr struct {
+	name    string
+	typ     string
+	keyType string
+}
+
+// keySchema is the key of a table or index as reported by
+// DynamoDB, the hash key first.
+type keySchema []keyAttr
+
+// newKeySchema resolves the types of keys from defs, returning
+// nil if one is missing.
+func newKeySchema(keys []KeyItem, defs []AttributeDefinition) keySchema {
+	var schema keySchema
+	for _, key := range keys {
+		attr := keyAttr{name: key.AttributeName, keyType: key.KeyType}
+		for _, def := range defs {
+			if def.AttributeName == key.AttributeName {
+				attr.typ = def.AttributeType
+			}
+		}
+		if attr.typ == "" || len(schema) == 2 {
+			return nil
+		}
+		if attr.keyType == "HASH" {
+			schema = append(keySchema{attr}, schema...)
+		} else {
+			schema = append(schema, attr)
+		}
+	}
+	return schema
+}
+
+// tableSchema holds the key schemas of a table and its
+// secondary indexes.
+type tableSchema struct {
+	key     keySchema
+	indexes map[string]keySchema
+}
+
+// newTableSchema extracts the key schemas described by desc, or
+// returns nil if it doesn't describe the table's key.
+func newTableSchema(desc *TableDesc) *tableSchema {
+	schema := &tableSchema{
+		key:     newKeySchema(desc.KeySchema, desc.AttributeDefinitions),
+		indexes: map[string]keySchema{},
+	}
+	if schema.key == nil {
+		return nil
+	}
+	for _, index := range desc.GlobalSecondaryIndexes {
+		schema.indexes[index.IndexName] = newKeySchema(index.KeySchema, desc.AttributeDefinitions)
+	}
+	for _, index := range desc.LocalSecondaryIndexes {
+		schema.indexes[index.IndexName] = newKeySchema(index.KeySchema, desc.AttributeDefinitions)
+	}
+	return schema
+}
+
+// KeyError reports a key which doesn't match the key schema of
+// its table. It is returned before the request is sent.
+type KeyError struct {
+	Table     string
+	Attribute string
+	Problem   string
+}
+
+func (e *KeyError) Error() string {
+	return fmt.Sprintf("dynamodb: table %s: key attribute %s %s", e.Table, e.Attribute, e.Problem)
+}
+
+// find returns the values of the key attributes in the encoded
+// item, nil for those it lacks.
+func (s keySchema) find(item []byte) ([2][]byte, error) {
+	var values [2][]byte
+	dec := Decoder{data: item}
+	for dec.Next() {
+		name := dec.Name()
+		value := dec.Raw()
+		for i, attr := range s {
+			if string(name) == attr.name {
+				values[i] = value
+			}
+		}
+	}
+	return values, dec.Err()
+}
+
+// check validates the encoded value of the attribute.
+func (a keyAttr) check(table string, value []byte) error {
+	if value == nil {
+		return &KeyError{table, a.name, "is missing"}
+	}
+	dec := Decoder{data: value}
+	if !dec.Next() {
+		return &KeyError{table, a.name, "has no value"}
+	}
+	if string(dec.Name()) != a.typ {
+		return &KeyError{table, a.name, fmt.Sprintf("is of type %s instead of %s", dec.Name(), a.typ)}
+	}
+	if string(dec.Raw()) == `""` {
+		return &KeyError{table, a.name, "is empty"}
+	}
+	return dec.Err()
+}
+
+// check validates the key attributes of the encoded item.
+func (s keySchema) check(table string, item []byte) error {
+	values, err := s.find(item)
+	if err != nil {
+		return err
+	}
+	for i, attr := range s {
+		if err := attr.check(table, values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encode writes the key of the encoded item to buf.
+func (s keySchema) encode(table string, item []byte, buf *bytes.Buffer) error {
+	values, err := s.find(item)
+	if err != nil {
+		return err
+	}
+	for i, attr := range s {
+		if err := attr.check(table, values[i]); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('{')
+	for i, attr := range s {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		writeString(buf, attr.name)
+		buf.WriteByte(':')
+		buf.Write(values[i])
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// keyItems lists the schema as KeyItems.
+func (s keySchema) keyItems() []KeyItem {
+	var keys []KeyItem
+	for _, attr := range s {
+		keys = append(keys, KeyItem{AttributeName: attr.name, KeyType: attr.keyType})
+	}
+	return keys
+}
+
+// KeySchema returns the key schema of the table, as last
+// reported by DescribeTable or CreateTable, or nil if neither
+// has been called on the table yet.
+func (t *Table) KeySchema() []KeyItem {
+	schema := t.loaded()
+	if schema == nil {
+		return nil
+	}
+	return schema.key.keyItems()
+}
+
+// IndexKeySchema returns the key schema of the secondary index
+// called name, or nil if it isn't known.
+func (t *Table) IndexKeySchema(name string) []KeyItem {
+	schema := t.loaded()
+	if schema == nil {
+		return nil
+	}
+	return schema.indexes[name].keyItems()
+}
+
+// Describe describes the table, loading its key schema and
+// those of its indexes. Keys are then built from items by
+// attribute name, whatever their struct tags, and validated
+// before requests are sent.
+func (t *Table) Describe(ctx context.Context) (*TableDesc, error) {
+	return t.client.DescribeTable(ctx, t.name)
+}
+
+// cache caches the key schemas in desc, or forgets them if desc
+// is nil.
+func (t *Table) cache(desc *TableDesc) {
+	var schema *tableSchema
+	if desc != nil {
+		schema = newTableSchema(desc)
+	}
+	t.mu.Lock()
+	t.schema = schema
+	t.mu.Unlock()
+}
+
+// loaded returns the cached schema, or nil.
+func (t *Table) loaded() *tableSchema {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.schema
+}
+
+// checkKey validates the key attributes of the encoded item
+// against the cached key schema, if there is one.
+func (t *Table) checkKey(item []byte) error {
+	schema := t.loaded()
+	if schema == nil {
+		return nil
+	}
+	return schema.key.check(t.name, item)
+}
+
+// encodeKey writes the key of item to buf. Once the key schema
+// has been loaded, the key attributes are taken from the whole
+// item by name; until then they are those tagged HASH and
+// RANGE.
+func (t *Table) encodeKey(item interface{}, buf *bytes.Buffer) error {
+	schema := t.loaded()
+	if schema == nil {
+		encode(item, buf, true, false)
+		return nil
+	}
+	encoded := getBuffer()
+	defer putBuffer(encoded)
+	encode(item, encoded, false, false)
+	return schema.key.encode(t.name, encoded.Bytes(), buf)
+}
+
+// indexSchema returns the key schema of the index, or of the
+// table if index is empty, from the loaded schema or else from
+// the tags of model, if not nil. It returns nil if neither is
+// known, and fails if the index isn't known to exist.
+func (t *Table) indexSchema(index string, model interface{}) (keySchema, error) {
+	var key keySchema
+	if schema := t.loaded(); schema != nil {
+		key = schema.key
+		if index != "" {
+			key = schema.indexes[index]
+		}
+	} else if model != nil {
+		var err error
+		if key, err = modelKeySchema(model, index); err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, nil
+	}
+	if key == nil {
+		if index == "" {
+			return nil, fmt.Errorf("dynamodb: table %s: key schema unknown", t.name)
+		}
+		return nil, fmt.Errorf("dynamodb: table %s: unknown index %s", t.name, index)
+	}
+	return key, nil
+}
+
+// hashCondition builds the condition matching the hash key of
+// the index, or of the table if index is empty, to that of item.
+func (t *Table) hashCondition(index string, item interface{}) (condition, error) {
+	key, err := t.indexSchema(index, item)
+	if err != nil {
+		return condition{}, err
+	}
+	encoded := getBuffer()
+	defer putBuffer(encoded)
+	encode(item, encoded, false, false)
+	values, err := key.find(encoded.Bytes())
+	if err != nil {
+		return condition{}, err
+	}
+	if err := key[0].check(t.name, values[0]); err != nil {
+		return condition{}, err
+	}
+	value := append([]byte(nil), values[0]...)
+	return condition{key[0].name, EQ, [][]byte{value}}, nil
+}
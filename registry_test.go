@@ -0,0 +1,136 @@
+package dynamodb
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestRegistry(t *testing.T) {
+	client := Dial(EndPoint("Test", "local", "localhost", false), Auth("key", "secret"), nil)
+	other := Dial(EndPoint("Test", "local", "localhost", false), Auth("key", "secret"), nil)
+
+	var wg sync.WaitGroup
+	tables := make([]*Table, 10)
+	for i := range tables {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tables[i] = client.Table("Test")
+		}(i)
+	}
+	wg.Wait()
+	for _, table := range tables {
+		if table != tables[0] {
+			t.Error("want one Table per name")
+		}
+	}
+	if client.Table("Other") == tables[0] || other.Table("Test") == tables[0] {
+		t.Error("want Tables per name and Client")
+	}
+}
+
+func TestKeySchema(t *testing.T) {
+	var sent int
+	var body string
+	server, client := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Target") == "DynamoDB_20120810.DescribeTable" {
+			w.Write([]byte(`{"Table":{
+				"TableName":"Test",
+				"AttributeDefinitions":[
+					{"AttributeName":"MyItem2","AttributeType":"S"},
+					{"AttributeName":"Weight","AttributeType":"N"},
+					{"AttributeName":"Height","AttributeType":"N"}
+				],
+				"KeySchema":[{"AttributeName":"Weight","KeyType":"RANGE"},{"AttributeName":"MyItem2","KeyType":"HASH"}],
+				"GlobalSecondaryIndexes":[{"IndexName":"ByHeight","KeySchema":[{"AttributeName":"Height","KeyType":"HASH"}]}]
+			}}`))
+			return
+		}
+		sent++
+		b, _ := ioutil.ReadAll(r.Body)
+		body = string(b)
+		w.Write([]byte(`{"Item":{}}`))
+	})
+	defer server.Close()
+
+	ctx := context.Background()
+	table := client.Table("Test")
+	if table.KeySchema() != nil {
+		t.Error("got", table.KeySchema())
+	}
+	if err := table.Put(ctx, &MyItem{}); err != nil || sent != 1 {
+		t.Error("want the request sent without a schema, got", err)
+	}
+
+	if _, err := table.Describe(ctx); err != nil {
+		t.Fatal(err)
+	}
+	keys := table.KeySchema()
+	if len(keys) != 2 || keys[0].AttributeName != "MyItem2" || keys[1].KeyType != "RANGE" {
+		t.Error("got", keys)
+	}
+
+	sent = 0
+	if err := table.Put(ctx, &MyItem{Name: "Tom", Weight: 80}); err != nil {
+		t.Error(err)
+	}
+	for _, err := range []error{
+		table.Put(ctx, &MyItem{Weight: 80}),
+		table.Put(ctx, &struct {
+			Name   string `ddb:"MyItem2,HASH"`
+			Weight string
+		}{"Tom", "80"}),
+		table.Put(ctx, &struct {
+			Name string `ddb:"MyItem2,HASH"`
+		}{"Tom"}),
+		table.Delete(ctx, &struct {
+			Name string `ddb:"MyItem2,HASH"`
+		}{"Tom"}),
+	} {
+		if _, ok := err.(*KeyError); !ok {
+			t.Error("want KeyError, got", err)
+		}
+	}
+	if sent != 1 {
+		t.Error("want invalid keys not to be sent, got", sent)
+	}
+	want := "dynamodb: table Test: key attribute MyItem2 is empty"
+	if err := table.Put(ctx, &MyItem{Weight: 80}); err == nil || err.Error() != want {
+		t.Error("want", want)
+		t.Error("got ", err)
+	}
+
+	// keys are built by attribute name rather than by tag
+	sent = 0
+	table.Delete(ctx, &MyItem{Name: "Tom", Weight: 80, Height: 179})
+	table.Get(ctx, &struct {
+		Weight int
+		Name   string `ddb:"MyItem2"`
+		Other  string `ddb:"Other,HASH"`
+	}{80, "Tom", "x"}, false)
+	if sent != 2 || !strings.Contains(body, `"Key":{"MyItem2":{"S":"Tom"},"Weight":{"N":"80"}}`) {
+		t.Error("got", body)
+	}
+	if keys := table.IndexKeySchema("ByHeight"); len(keys) != 1 || keys[0].AttributeName != "Height" {
+		t.Error("got", keys)
+	}
+
+	// queries match the hash key of their index
+	table.Query().Index("ByHeight").Match(&MyItem{Name: "Tom", Height: 179}).Items(ctx, false)
+	if !strings.Contains(body, `"KeyConditions":{"Height":{"ComparisonOperator":"EQ", "AttributeValueList":[{"N":"179"}]}}`) {
+		t.Error("got", body)
+	}
+	if _, err := table.Query().Index("ByWidth").Match(&MyItem{}).Items(ctx, false); err == nil {
+		t.Error("want error for unknown index")
+	}
+
+	// the schema is shared by the Client's Tables
+	if len(client.Table("Test").KeySchema()) != 2 {
+		t.Error("want schema cached")
+	}
+}
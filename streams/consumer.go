@@ -0,0 +1,231 @@
+// Public Domain (-) 2012-2013 The Go DynamoDB Authors.
+// See the Go DynamoDB UNLICENSE file for details.
+
+package streams
+
+import (
+	"sync"
+	"time"
+
+	"github.com/groupme/dynamodb-1"
+	"golang.org/x/net/context"
+)
+
+// ShardEnd is the checkpoint of a shard which has been read
+// completely.
+const ShardEnd = "SHARD_END"
+
+// Store persists the checkpoints of a Consumer, i.e. the
+// sequence number of the last record handled in each shard.
+type Store interface {
+	// Load returns the checkpoint of the shard of stream arn,
+	// or an empty string if there is none.
+	Load(ctx context.Context, arn, shardID string) (string, error)
+
+	// Save sets the checkpoint of the shard of stream arn to
+	// sequenceNumber, which may be ShardEnd.
+	Save(ctx context.Context, arn, shardID, sequenceNumber string) error
+}
+
+// MemoryStore is a Store which keeps the checkpoints in memory,
+// e.g. for consumers which needn't resume after a restart.
+type MemoryStore struct {
+	mu          sync.Mutex
+	checkpoints map[string]string
+}
+
+// Load implements Store.
+func (s *MemoryStore) Load(ctx context.Context, arn, shardID string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.checkpoints[arn+"/"+shardID], nil
+}
+
+// Save implements Store.
+func (s *MemoryStore) Save(ctx context.Context, arn, shardID, sequenceNumber string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.checkpoints == nil {
+		s.checkpoints = map[string]string{}
+	}
+	s.checkpoints[arn+"/"+shardID] = sequenceNumber
+	return nil
+}
+
+// Handler handles records read from a shard, in order. The
+// records are checkpointed once it returns nil.
+type Handler func(ctx context.Context, shardID string, records []Record) error
+
+// Consumer reads every shard of a stream, those open as well as
+// their descendants, and passes their records to a Handler.
+// Shards are read concurrently, but only once their parent has
+// been read completely, so the changes to an item are handled
+// in order.
+type Consumer struct {
+	Client    *Client
+	StreamArn string
+	Handler   Handler
+
+	// Store holds the checkpoints. It defaults to a
+	// MemoryStore.
+	Store Store
+
+	// StartAt is where shards without a checkpoint are read
+	// from, TrimHorizon, the default, or Latest. Children of
+	// shards read by the Consumer are always read from their
+	// start.
+	StartAt string
+
+	// Limit is the maximum number of records passed to the
+	// Handler at once, 1000 if 0.
+	Limit int
+
+	// PollInterval is the time waited for new records once
+	// the end of an open shard has been reached, and between
+	// looking for new shards. It defaults to a second.
+	PollInterval time.Duration
+}
+
+// shardResult is the outcome of consuming a shard.
+type shardResult struct {
+	shardID string
+	err     error
+}
+
+// Run consumes the stream until ctx is done or the Handler, the
+// Store or a call fails, and returns the error. It returns nil
+// once the stream is disabled and all of its shards are read.
+func (c *Consumer) Run(ctx context.Context) error {
+	if c.Store == nil {
+		c.Store = &MemoryStore{}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	running := map[string]bool{}
+	finished := map[string]bool{}
+	results := make(chan shardResult)
+	var err error
+	for err == nil {
+		var desc *StreamDescription
+		desc, err = c.Client.DescribeStream(ctx, c.StreamArn)
+		if err != nil {
+			break
+		}
+		known := map[string]bool{}
+		for _, shard := range desc.Shards {
+			known[shard.ShardId] = true
+		}
+		pending := false
+		for _, shard := range Lineage(desc.Shards) {
+			id := shard.ShardId
+			if running[id] || finished[id] {
+				continue
+			}
+			parent := shard.ParentShardId
+			if known[parent] && !finished[parent] {
+				pending = true
+				continue
+			}
+			var checkpoint string
+			if checkpoint, err = c.Store.Load(ctx, c.StreamArn, id); err != nil {
+				break
+			}
+			if checkpoint == ShardEnd {
+				finished[id] = true
+				continue
+			}
+			// children continue where their parent ended
+			start := c.StartAt
+			if start == "" || known[parent] {
+				start = TrimHorizon
+			}
+			running[id] = true
+			go func(shard Shard) {
+				results <- shardResult{shard.ShardId, c.consume(ctx, shard, start, checkpoint)}
+			}(shard)
+		}
+		if err != nil {
+			break
+		}
+		if len(running) == 0 && !pending && desc.StreamStatus == "DISABLED" {
+			return nil
+		}
+
+		select {
+		case r := <-results:
+			delete(running, r.shardID)
+			finished[r.shardID] = true
+			err = r.err
+		case <-time.After(c.pollInterval()):
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+
+	// stop the other shards
+	cancel()
+	for len(running) > 0 {
+		r := <-results
+		delete(running, r.shardID)
+	}
+	return err
+}
+
+// consume reads shard from checkpoint, or start without one,
+// until it is closed and read completely.
+func (c *Consumer) consume(ctx context.Context, shard Shard, start, checkpoint string) error {
+	iterator, err := c.iterator(ctx, shard.ShardId, start, checkpoint)
+	if err != nil {
+		return err
+	}
+	for {
+		records, next, err := c.Client.GetRecords(ctx, iterator, c.Limit)
+		if e, ok := err.(dynamodb.Error); ok && e.Type() == "ExpiredIteratorException" {
+			iterator, err = c.iterator(ctx, shard.ShardId, start, checkpoint)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if len(records) > 0 {
+			if err := c.Handler(ctx, shard.ShardId, records); err != nil {
+				return err
+			}
+			checkpoint = records[len(records)-1].Dynamodb.SequenceNumber
+			if err := c.Store.Save(ctx, c.StreamArn, shard.ShardId, checkpoint); err != nil {
+				return err
+			}
+		}
+		if next == "" {
+			return c.Store.Save(ctx, c.StreamArn, shard.ShardId, ShardEnd)
+		}
+		iterator = next
+		if len(records) == 0 {
+			select {
+			case <-time.After(c.pollInterval()):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// iterator returns an iterator of the shard following the
+// record at checkpoint, or of type start without one.
+func (c *Consumer) iterator(ctx context.Context, shardID, start, checkpoint string) (string, error) {
+	if checkpoint != "" {
+		return c.Client.GetShardIterator(ctx, c.StreamArn, shardID, AfterSequenceNumber, checkpoint)
+	}
+	return c.Client.GetShardIterator(ctx, c.StreamArn, shardID, start, "")
+}
+
+func (c *Consumer) pollInterval() time.Duration {
+	if c.PollInterval == 0 {
+		return time.Second
+	}
+	return c.PollInterval
+}
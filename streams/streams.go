@@ -0,0 +1,304 @@
+// Public Domain (-) 2012-2013 The Go DynamoDB Authors.
+// See the Go DynamoDB UNLICENSE file for details.
+
+// Package streams reads the changes to DynamoDB tables from
+// their DynamoDB Streams. A Client calls the Streams API with a
+// dynamodb.Client dialed with dynamodb.DialStreams:
+//
+//     c := streams.New(dynamodb.DialStreams(
+//         dynamodb.StreamsEndPoint(dynamodb.USEast1), auth, nil))
+//     list, err := c.ListStreams(ctx, "users")
+//
+// A stream is split into shards, which are closed after about
+// four hours and replaced by child shards. A Consumer reads
+// every shard of a stream, the parents before their children,
+// and checkpoints its position in each to a Store so that it
+// resumes where it left off:
+//
+//     consumer := &streams.Consumer{
+//         Client:    c,
+//         StreamArn: list[0].StreamArn,
+//         Store:     store,
+//         Handler: func(ctx context.Context, shardID string, records []streams.Record) error {
+//             for _, r := range records {
+//                 var user User
+//                 if err := r.Dynamodb.DecodeNewImage(&user); err != nil {
+//                     return err
+//                 }
+//                 ...
+//             }
+//             return nil
+//         },
+//     }
+//     err = consumer.Run(ctx)
+//
+// Images decode into the same structs as items, with the
+// dynamodb package's decoder.
+package streams
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/groupme/dynamodb-1"
+	"golang.org/x/net/context"
+)
+
+// Types of shard iterators.
+const (
+	TrimHorizon         = "TRIM_HORIZON"
+	Latest              = "LATEST"
+	AtSequenceNumber    = "AT_SEQUENCE_NUMBER"
+	AfterSequenceNumber = "AFTER_SEQUENCE_NUMBER"
+)
+
+// Names of the events of records.
+const (
+	Insert = "INSERT"
+	Modify = "MODIFY"
+	Remove = "REMOVE"
+)
+
+// ErrNoImage is returned when decoding an image which the
+// stream doesn't include in its records.
+var ErrNoImage = errors.New("streams: record has no such image")
+
+// Client calls the DynamoDB Streams API.
+type Client struct {
+	c *dynamodb.Client
+}
+
+// New creates a Client calling the API with c, which must have
+// been dialed with dynamodb.DialStreams.
+func New(c *dynamodb.Client) *Client {
+	return &Client{c}
+}
+
+// Stream identifies a stream of a table.
+type Stream struct {
+	StreamArn   string
+	StreamLabel string
+	TableName   string
+}
+
+// StreamDescription describes a stream and its shards.
+type StreamDescription struct {
+	StreamArn               string
+	StreamLabel             string
+	StreamStatus            string
+	StreamViewType          string
+	TableName               string
+	CreationRequestDateTime float64
+	KeySchema               []dynamodb.KeyItem
+	Shards                  []Shard
+}
+
+// Shard is a shard of a stream.
+type Shard struct {
+	ShardId             string
+	ParentShardId       string `json:",omitempty"`
+	SequenceNumberRange SequenceNumberRange
+}
+
+// SequenceNumberRange is the range of the records of a shard.
+// It has no end while the shard is open.
+type SequenceNumberRange struct {
+	StartingSequenceNumber string
+	EndingSequenceNumber   string `json:",omitempty"`
+}
+
+// Closed reports whether no records are added to s anymore.
+func (s *Shard) Closed() bool {
+	return s.SequenceNumberRange.EndingSequenceNumber != ""
+}
+
+// Record is a change to an item of a table.
+type Record struct {
+	EventID      string
+	EventName    string
+	EventVersion string
+	EventSource  string
+	AwsRegion    string
+	Dynamodb     StreamRecord
+}
+
+// StreamRecord holds the key of the changed item and, depending
+// on the view type of the stream, its images before and after
+// the change, as the JSON of items, e.g. {"id":{"S":"foo"}}.
+type StreamRecord struct {
+	ApproximateCreationDateTime float64
+	SequenceNumber              string
+	SizeBytes                   int64
+	StreamViewType              string
+	Keys                        json.RawMessage
+	NewImage                    json.RawMessage `json:",omitempty"`
+	OldImage                    json.RawMessage `json:",omitempty"`
+}
+
+// DecodeKeys decodes the key attributes of the item into v, a
+// pointer to a struct as for dynamodb.Unmarshal.
+func (r *StreamRecord) DecodeKeys(v interface{}) error {
+	return decodeImage(r.Keys, v)
+}
+
+// DecodeNewImage decodes the item as it is after the change
+// into v. It returns ErrNoImage for REMOVE events and streams
+// which don't record new images.
+func (r *StreamRecord) DecodeNewImage(v interface{}) error {
+	return decodeImage(r.NewImage, v)
+}
+
+// DecodeOldImage decodes the item as it was before the change
+// into v. It returns ErrNoImage for INSERT events and streams
+// which don't record old images.
+func (r *StreamRecord) DecodeOldImage(v interface{}) error {
+	return decodeImage(r.OldImage, v)
+}
+
+func decodeImage(data json.RawMessage, v interface{}) error {
+	if len(data) == 0 || string(data) == "null" {
+		return ErrNoImage
+	}
+	return dynamodb.Unmarshal(data, v)
+}
+
+// ListStreams lists the streams of the table called table, or
+// of every table if it is empty.
+func (c *Client) ListStreams(ctx context.Context, table string) ([]Stream, error) {
+	var streams []Stream
+	args := dynamodb.Map{}
+	if table != "" {
+		args["TableName"] = table
+	}
+	for {
+		payload, err := c.c.Call(ctx, "ListStreams", args)
+		if err != nil {
+			return nil, err
+		}
+		var resp struct {
+			Streams                []Stream
+			LastEvaluatedStreamArn string
+		}
+		if err := json.Unmarshal(payload, &resp); err != nil {
+			return nil, err
+		}
+		streams = append(streams, resp.Streams...)
+		if resp.LastEvaluatedStreamArn == "" {
+			return streams, nil
+		}
+		args["ExclusiveStartStreamArn"] = resp.LastEvaluatedStreamArn
+	}
+}
+
+// DescribeStream describes the stream arn with all of its
+// shards, which may take several calls.
+func (c *Client) DescribeStream(ctx context.Context, arn string) (*StreamDescription, error) {
+	var desc *StreamDescription
+	args := dynamodb.Map{"StreamArn": arn}
+	for {
+		payload, err := c.c.Call(ctx, "DescribeStream", args)
+		if err != nil {
+			return nil, err
+		}
+		var resp struct {
+			StreamDescription struct {
+				StreamDescription
+				LastEvaluatedShardId string
+			}
+		}
+		if err := json.Unmarshal(payload, &resp); err != nil {
+			return nil, err
+		}
+		page := &resp.StreamDescription
+		if desc == nil {
+			desc = &page.StreamDescription
+		} else {
+			desc.Shards = append(desc.Shards, page.Shards...)
+		}
+		if page.LastEvaluatedShardId == "" {
+			return desc, nil
+		}
+		args["ExclusiveStartShardId"] = page.LastEvaluatedShardId
+	}
+}
+
+// GetShardIterator returns an iterator of the shard of stream
+// arn of iteratorType, one of the iterator type constants.
+// sequenceNumber is the position of the iterator for
+// AtSequenceNumber and AfterSequenceNumber and ignored
+// otherwise.
+func (c *Client) GetShardIterator(
+	ctx context.Context,
+	arn string,
+	shardID string,
+	iteratorType string,
+	sequenceNumber string,
+) (string, error) {
+	args := dynamodb.Map{
+		"StreamArn":         arn,
+		"ShardId":           shardID,
+		"ShardIteratorType": iteratorType,
+	}
+	if iteratorType == AtSequenceNumber || iteratorType == AfterSequenceNumber {
+		args["SequenceNumber"] = sequenceNumber
+	}
+	payload, err := c.c.Call(ctx, "GetShardIterator", args)
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		ShardIterator string
+	}
+	err = json.Unmarshal(payload, &resp)
+	return resp.ShardIterator, err
+}
+
+// GetRecords returns up to limit records of a shard, or up to
+// 1000 if limit is 0, from iterator and the iterator of the
+// following ones. The next iterator is empty once a closed
+// shard has been read completely.
+func (c *Client) GetRecords(
+	ctx context.Context,
+	iterator string,
+	limit int,
+) (records []Record, next string, err error) {
+	args := dynamodb.Map{"ShardIterator": iterator}
+	if limit != 0 {
+		args["Limit"] = limit
+	}
+	payload, err := c.c.Call(ctx, "GetRecords", args)
+	if err != nil {
+		return nil, "", err
+	}
+	var resp struct {
+		Records           []Record
+		NextShardIterator string
+	}
+	err = json.Unmarshal(payload, &resp)
+	return resp.Records, resp.NextShardIterator, err
+}
+
+// Lineage orders shards so that every shard follows its parent.
+// Shards whose parent isn't among them, e.g. because it was
+// trimmed from the stream, come first.
+func Lineage(shards []Shard) []Shard {
+	known := map[string]bool{}
+	children := map[string][]Shard{}
+	for _, shard := range shards {
+		known[shard.ShardId] = true
+	}
+	var ordered, roots []Shard
+	for _, shard := range shards {
+		if known[shard.ParentShardId] {
+			children[shard.ParentShardId] = append(children[shard.ParentShardId], shard)
+		} else {
+			roots = append(roots, shard)
+		}
+	}
+	for len(roots) > 0 {
+		shard := roots[0]
+		roots = append(roots[1:], children[shard.ShardId]...)
+		ordered = append(ordered, shard)
+	}
+	return ordered
+}
@@ -0,0 +1,231 @@
+package streams
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/groupme/dynamodb-1"
+	"golang.org/x/net/context"
+)
+
+const arn = "arn:aws:dynamodb:local:0:table/users/stream/1"
+
+type user struct {
+	ID   string `ddb:"id,HASH"`
+	Name string `ddb:"name"`
+}
+
+// newTestClient serves the Streams API with handler, which is
+// passed the operation and its arguments.
+func newTestClient(handler func(target string, args map[string]interface{}) string) (*httptest.Server, *Client) {
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target := r.Header.Get("X-Amz-Target")
+		if !strings.HasPrefix(target, "DynamoDBStreams_20120810.") {
+			w.WriteHeader(400)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		var args map[string]interface{}
+		json.Unmarshal(body, &args)
+		mu.Lock()
+		resp := handler(target[len("DynamoDBStreams_20120810."):], args)
+		mu.Unlock()
+		if strings.Contains(resp, "__type") {
+			w.WriteHeader(400)
+		}
+		w.Write([]byte(resp))
+	}))
+	u, _ := url.Parse(server.URL)
+	c := dynamodb.DialStreams(dynamodb.EndPoint("Test", "local", u.Host, false), dynamodb.Auth("key", "secret"), nil)
+	c.ErrorLog = nil
+	return server, New(c)
+}
+
+func TestStreamsEndPoint(t *testing.T) {
+	if got := dynamodb.StreamsEndPoint(dynamodb.EUWest1).String(); got != "<Ireland: streams.dynamodb.eu-west-1.amazonaws.com>" {
+		t.Error("got", got)
+	}
+	local := dynamodb.EndPoint("dev", "local", "localhost:8000", false)
+	if got := dynamodb.StreamsEndPoint(local); got != local {
+		t.Error("got", got)
+	}
+}
+
+func TestListAndDescribe(t *testing.T) {
+	server, c := newTestClient(func(target string, args map[string]interface{}) string {
+		switch target {
+		case "ListStreams":
+			if args["ExclusiveStartStreamArn"] == nil {
+				return `{"Streams":[{"StreamArn":"a","TableName":"users"}],"LastEvaluatedStreamArn":"a"}`
+			}
+			return `{"Streams":[{"StreamArn":"b","TableName":"users"}]}`
+		case "DescribeStream":
+			if args["ExclusiveStartShardId"] == nil {
+				return `{"StreamDescription":{"StreamArn":"a","StreamStatus":"ENABLED","Shards":[{"ShardId":"1"}],"LastEvaluatedShardId":"1"}}`
+			}
+			return `{"StreamDescription":{"StreamArn":"a","StreamStatus":"ENABLED","Shards":[{"ShardId":"2","ParentShardId":"1"}]}}`
+		}
+		return `{}`
+	})
+	defer server.Close()
+
+	ctx := context.Background()
+	list, err := c.ListStreams(ctx, "users")
+	if err != nil || len(list) != 2 || list[1].StreamArn != "b" {
+		t.Error("got", list, err)
+	}
+	desc, err := c.DescribeStream(ctx, "a")
+	if err != nil || len(desc.Shards) != 2 || desc.Shards[1].ParentShardId != "1" || desc.StreamStatus != "ENABLED" {
+		t.Error("got", desc, err)
+	}
+}
+
+func TestLineage(t *testing.T) {
+	shards := []Shard{
+		{ShardId: "c", ParentShardId: "b"},
+		{ShardId: "b", ParentShardId: "a"},
+		{ShardId: "d", ParentShardId: "a"},
+		{ShardId: "a", ParentShardId: "trimmed"},
+		{ShardId: "e"},
+	}
+	var got []string
+	for _, shard := range Lineage(shards) {
+		got = append(got, shard.ShardId)
+	}
+	if want := []string{"a", "e", "b", "d", "c"}; !reflect.DeepEqual(got, want) {
+		t.Error("want", want)
+		t.Error("got ", got)
+	}
+}
+
+func TestDecodeImage(t *testing.T) {
+	var r Record
+	json.Unmarshal([]byte(`{"eventName":"MODIFY","dynamodb":{
+		"Keys":{"id":{"S":"1"}},
+		"NewImage":{"id":{"S":"1"},"name":{"S":"new"}},
+		"SequenceNumber":"100"
+	}}`), &r)
+	var u user
+	if err := r.Dynamodb.DecodeNewImage(&u); err != nil || u != (user{"1", "new"}) || r.EventName != Modify {
+		t.Error("got", u, err)
+	}
+	if err := r.Dynamodb.DecodeOldImage(&u); err != ErrNoImage {
+		t.Error("got", err)
+	}
+	var key user
+	if err := r.Dynamodb.DecodeKeys(&key); err != nil || key.ID != "1" {
+		t.Error("got", key, err)
+	}
+}
+
+func TestConsumer(t *testing.T) {
+	// shard 1 is closed with two pages of records and shard 2,
+	// its child, with one, after an expired iterator. The child
+	// is read from its start rather than StartAt.
+	expired := false
+	server, c := newTestClient(func(target string, args map[string]interface{}) string {
+		switch target {
+		case "DescribeStream":
+			return `{"StreamDescription":{"StreamArn":"` + arn + `","StreamStatus":"DISABLED","Shards":[
+				{"ShardId":"2","ParentShardId":"1","SequenceNumberRange":{"StartingSequenceNumber":"300","EndingSequenceNumber":"300"}},
+				{"ShardId":"1","SequenceNumberRange":{"StartingSequenceNumber":"100","EndingSequenceNumber":"200"}}
+			]}}`
+		case "GetShardIterator":
+			return fmt.Sprintf(`{"ShardIterator":"%s/%s/%v"}`, args["ShardId"], args["ShardIteratorType"], args["SequenceNumber"])
+		case "GetRecords":
+			switch args["ShardIterator"] {
+			case "1/LATEST/<nil>":
+				return `{"Records":[{"eventName":"INSERT","dynamodb":{"SequenceNumber":"100","NewImage":{"id":{"S":"a"}}}}],"NextShardIterator":"1/next"}`
+			case "1/next":
+				return `{"Records":[{"eventName":"MODIFY","dynamodb":{"SequenceNumber":"200","NewImage":{"id":{"S":"b"}}}}]}`
+			case "2/TRIM_HORIZON/<nil>":
+				if !expired {
+					expired = true
+					return `{"__type":"com.amazonaws.dynamodb.v20120810#ExpiredIteratorException","message":"expired"}`
+				}
+				return `{"Records":[{"eventName":"REMOVE","dynamodb":{"SequenceNumber":"300","OldImage":{"id":{"S":"c"}}}}]}`
+			}
+		}
+		return `{}`
+	})
+	defer server.Close()
+
+	var handled []string
+	store := &MemoryStore{}
+	consumer := &Consumer{
+		Client:       c,
+		StreamArn:    arn,
+		Store:        store,
+		StartAt:      Latest,
+		PollInterval: time.Millisecond,
+		Handler: func(ctx context.Context, shardID string, records []Record) error {
+			for _, r := range records {
+				var u user
+				if err := r.Dynamodb.DecodeNewImage(&u); err != nil {
+					r.Dynamodb.DecodeOldImage(&u)
+				}
+				handled = append(handled, shardID+":"+r.EventName+":"+u.ID)
+			}
+			return nil
+		},
+	}
+	if err := consumer.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"1:INSERT:a", "1:MODIFY:b", "2:REMOVE:c"}
+	if !reflect.DeepEqual(handled, want) {
+		t.Error("want", want)
+		t.Error("got ", handled)
+	}
+	for _, id := range []string{"1", "2"} {
+		if cp, _ := store.Load(nil, arn, id); cp != ShardEnd {
+			t.Error("checkpoint", id, cp)
+		}
+	}
+
+	// a finished stream is skipped
+	handled = nil
+	if err := consumer.Run(context.Background()); err != nil || handled != nil {
+		t.Error("got", handled, err)
+	}
+}
+
+func TestConsumerResumes(t *testing.T) {
+	var iterators []string
+	server, c := newTestClient(func(target string, args map[string]interface{}) string {
+		switch target {
+		case "DescribeStream":
+			return `{"StreamDescription":{"StreamArn":"` + arn + `","StreamStatus":"ENABLED","Shards":[{"ShardId":"1"}]}}`
+		case "GetShardIterator":
+			it := fmt.Sprintf("%s/%v", args["ShardIteratorType"], args["SequenceNumber"])
+			iterators = append(iterators, it)
+			return `{"ShardIterator":"` + it + `"}`
+		case "GetRecords":
+			return `{"Records":[],"NextShardIterator":"next"}`
+		}
+		return `{}`
+	})
+	defer server.Close()
+
+	store := &MemoryStore{}
+	store.Save(nil, arn, "1", "150")
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	consumer := &Consumer{Client: c, StreamArn: arn, Store: store, PollInterval: time.Millisecond}
+	if err := consumer.Run(ctx); err != context.DeadlineExceeded {
+		t.Error("got", err)
+	}
+	if len(iterators) != 1 || iterators[0] != "AFTER_SEQUENCE_NUMBER/150" {
+		t.Error("got", iterators)
+	}
+}
@@ -0,0 +1,382 @@
+package dynamodb
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestItemOptions(t *testing.T) {
+	var body string
+	server, client := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body = string(b)
+		w.Write([]byte(`{
+			"ConsumedCapacity": {
+				"TableName": "Test",
+				"CapacityUnits": 3,
+				"Table": {"CapacityUnits": 1},
+				"LocalSecondaryIndexes": {"ByWeight": {"CapacityUnits": 2}}
+			},
+			"ItemCollectionMetrics": {
+				"ItemCollectionKey": {"MyItem2": {"S": "Tom"}},
+				"SizeEstimateRangeGB": [0, 1]
+			}
+		}`))
+	})
+	defer server.Close()
+
+	session := client.Table("Test").Session(context.Background())
+	result, err := session.PutWith(&MyItem{Name: "Tom"}, &ItemOptions{
+		ReturnConsumedCapacity:      ReturnIndexes,
+		ReturnItemCollectionMetrics: ReturnSize,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(body, `, "ReturnConsumedCapacity":"INDEXES", "ReturnItemCollectionMetrics":"SIZE"}`) {
+		t.Error("got", body)
+	}
+	cc := result.ConsumedCapacity
+	if cc == nil || cc.CapacityUnits != 3 || cc.LocalSecondaryIndexes["ByWeight"].CapacityUnits != 2 {
+		t.Error("got", cc)
+	}
+	icm := result.ItemCollectionMetrics
+	if icm == nil || icm.ItemCollectionKey["MyItem2"]["S"] != "Tom" || icm.SizeEstimateRangeGB[1] != 1 {
+		t.Error("got", icm)
+	}
+
+	// Get never asks for item collection metrics
+	session.GetWith(&MyItem{Name: "Tom"}, true, &ItemOptions{
+		ReturnConsumedCapacity:      ReturnTotal,
+		ReturnItemCollectionMetrics: ReturnSize,
+	})
+	if !strings.HasSuffix(body, `"ConsistentRead":true, "ReturnConsumedCapacity":"TOTAL"}`) {
+		t.Error("got", body)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	var bodies []string
+	server, client := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		if len(bodies) == 1 {
+			w.Write([]byte(`{"Count":2,"Items":[{"id":{"S":"a"}},{"id":{"S":"b"}}],"LastEvaluatedKey":{"id":{"S":"b"}}}`))
+		} else {
+			w.Write([]byte(`{"Count":1,"Items":[{"id":{"S":"c"}}]}`))
+		}
+	})
+	defer server.Close()
+
+	items, err := client.Table("Test").Query().
+		Where("id", EQ, []byte(`{"S":"a"}`)).
+		Where("created", Between, []byte(`{"N":"1"}`), []byte(`{"N":"2"}`)).
+		Index("ByCreated").
+		Sort('-').
+		Limit(5).
+		Items(context.Background(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 3 || items[2]["id"]["S"] != "c" {
+		t.Error("got", items)
+	}
+	want := `{"TableName":"Test", "ConsistentRead":true, "KeyConditions":{` +
+		`"id":{"ComparisonOperator":"EQ", "AttributeValueList":[{"S":"a"}]}, ` +
+		`"created":{"ComparisonOperator":"BETWEEN", "AttributeValueList":[{"N":"1"},{"N":"2"}]}}, ` +
+		`"IndexName":"ByCreated", "Limit":5, "ScanIndexForward":false}`
+	if len(bodies) != 2 || bodies[0] != want {
+		t.Error("want", want)
+		t.Error("got ", bodies)
+	}
+	if len(bodies) == 2 && !strings.HasSuffix(bodies[1], `"Limit":3, "ScanIndexForward":false, "ExclusiveStartKey":{"id":{"S":"b"}}}`) {
+		t.Error("got", bodies[1])
+	}
+}
+
+func TestTableNameEscaped(t *testing.T) {
+	var bodies []string
+	server, client := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		w.Write([]byte(`{}`))
+	})
+	defer server.Close()
+
+	ctx := context.Background()
+	table := client.Table(`Test", "Key":{}`)
+	table.Put(ctx, &MyItem{Name: "Tom"})
+	table.Add(ctx, &MyItem{Name: "Tom"})
+	table.Delete(ctx, &MyItem{Name: "Tom"})
+	table.Query().Where("MyItem2", EQ, []byte(`{"S":"Tom"}`)).Items(ctx, false)
+	for _, body := range bodies {
+		var req struct{ TableName string }
+		if err := json.Unmarshal([]byte(body), &req); err != nil || req.TableName != table.name {
+			t.Error("got", body, err)
+		}
+	}
+	if len(bodies) != 4 {
+		t.Error("got", bodies)
+	}
+}
+
+// roundTripper answers every request with an empty object,
+// reading the request body like a transport would.
+type roundTripper struct{}
+
+func (roundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	io.Copy(ioutil.Discard, r.Body)
+	r.Body.Close()
+	return &http.Response{
+		StatusCode: 200,
+		Body:       ioutil.NopCloser(strings.NewReader(`{}`)),
+	}, nil
+}
+
+// BenchmarkPutRequest measures BenchmarkTablePut without the
+// local DynamoDB server.
+func BenchmarkPutRequest(b *testing.B) {
+	client := Dial(EndPoint("Test", "local", "localhost", false), Auth("key", "secret"), roundTripper{})
+	table := client.Table("Test")
+	ctx := context.Background()
+	item := &MyItem{Name: "Tom", Weight: 80, Height: 179}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := table.Put(ctx, item); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestCreateTableWith(t *testing.T) {
+	var body string
+	server, client := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body = string(b)
+		w.Write([]byte(`{"TableDescription":{"TableName":"Test","TableStatus":"CREATING"}}`))
+	})
+	defer server.Close()
+
+	ctx := context.Background()
+	_, err := client.CreateTableWith(ctx, "Test", &MyItem{}, &TableOptions{
+		BillingMode: BillingPayPerRequest,
+		GlobalIndexes: []GlobalIndex{{Index: Index{
+			IndexName:  "ByWeight",
+			KeySchema:  []KeyItem{{"Weight", "HASH"}, {"Height", "RANGE"}},
+			Projection: Projection{ProjectionType: "KEYS_ONLY"},
+		}}},
+		Stream:     &StreamSpecification{StreamEnabled: true, StreamViewType: StreamNewImage},
+		SSE:        &SSESpecification{Enabled: true},
+		Tags:       []Tag{{"team", "chat"}},
+		TableClass: TableClassStandardInfrequentAccess,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var req map[string]interface{}
+	json.Unmarshal([]byte(body), &req)
+	defs, _ := json.Marshal(req["AttributeDefinitions"])
+	want := `[{"AttributeName":"MyItem2","AttributeType":"S"},{"AttributeName":"Weight","AttributeType":"N"},{"AttributeName":"Height","AttributeType":"N"}]`
+	if string(defs) != want {
+		t.Error("want", want)
+		t.Error("got ", string(defs))
+	}
+	if req["BillingMode"] != "PAY_PER_REQUEST" || req["ProvisionedThroughput"] != nil || req["TableClass"] != TableClassStandardInfrequentAccess {
+		t.Error("got", body)
+	}
+	if !strings.Contains(body, `"GlobalSecondaryIndexes":[{"IndexName":"ByWeight","KeySchema":[{"AttributeName":"Weight","KeyType":"HASH"},{"AttributeName":"Height","KeyType":"RANGE"}],"Projection":{"NonKeyAttributes":null,"ProjectionType":"KEYS_ONLY"}}]`) {
+		t.Error("got", body)
+	}
+	if !strings.Contains(body, `"StreamSpecification":{"StreamEnabled":true,"StreamViewType":"NEW_IMAGE"}`) || !strings.Contains(body, `"Tags":[{"Key":"team","Value":"chat"}]`) {
+		t.Error("got", body)
+	}
+
+	// global indexes default to the table's throughput
+	client.CreateTable(ctx, "Test", &MyItem{}, 5, 6, []GlobalIndex{{Index: Index{
+		IndexName: "ByWeight",
+		KeySchema: []KeyItem{{"Weight", "HASH"}},
+	}}}, nil)
+	if strings.Count(body, `"ProvisionedThroughput":{"ReadCapacityUnits":5,"WriteCapacityUnits":6}`) != 2 || strings.Contains(body, "BillingMode") {
+		t.Error("got", body)
+	}
+
+	// index keys must be fields of the schema item
+	_, err = client.CreateTableWith(ctx, "Test", &MyItem{}, &TableOptions{
+		LocalIndexes: []Index{{IndexName: "ByAge", KeySchema: []KeyItem{{"MyItem2", "HASH"}, {"Age", "RANGE"}}}},
+	})
+	if err == nil {
+		t.Error("want error for undefined key attribute")
+	}
+}
+
+type indexedItem struct {
+	ID     string    `ddb:"id,HASH"`
+	Email  string    `ddb:"email,gsi=ByEmail:HASH:KEYS_ONLY"`
+	Team   string    `ddb:"team,gsi=ByTeam:HASH"`
+	Joined time.Time `ddb:"joined,gsi=ByTeam:RANGE,lsi=ByJoined:RANGE"`
+	Name   string    `ddb:"name,project=ByJoined"`
+}
+
+func TestUpdateTable(t *testing.T) {
+	var bodies []string
+	server, client := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		w.Write([]byte(`{"TableDescription":{"TableName":"Test","TableStatus":"UPDATING"}}`))
+	})
+	defer server.Close()
+
+	ctx := context.Background()
+	client.UpdateTable(ctx, "Test", 5, 1, nil)
+	client.UpdateTable(ctx, "Test", 0, 0, []GlobalIndexUpdate{DeleteIndex("ByName")})
+	client.UpdateTableWith(ctx, "Test", &TableUpdate{
+		AttributeDefinitions: []AttributeDefinition{{"Email", "S"}},
+		GlobalSecondaryIndexUpdates: []GlobalIndexUpdate{CreateIndex(GlobalIndex{Index: Index{
+			IndexName:  "ByEmail",
+			KeySchema:  []KeyItem{{"Email", "HASH"}},
+			Projection: Projection{ProjectionType: ProjectKeysOnly},
+		}})},
+	})
+	client.UpdateTableWith(ctx, "Test", &TableUpdate{
+		BillingMode:                 BillingProvisioned,
+		ProvisionedThroughput:       &ProvisionedThroughput{2, 2},
+		GlobalSecondaryIndexUpdates: []GlobalIndexUpdate{UpdateIndex("ByEmail", ProvisionedThroughput{1, 1})},
+		StreamSpecification:         &StreamSpecification{StreamEnabled: false},
+	})
+	var update GlobalIndexUpdate
+	update.Update.IndexName = "ByName"
+	update.Update.ProvisionedThroughput = ProvisionedThroughput{3, 3}
+	client.UpdateTable(ctx, "Test", 0, 0, []GlobalIndexUpdate{update})
+	want := []string{
+		`{"TableName":"Test","ProvisionedThroughput":{"ReadCapacityUnits":5,"WriteCapacityUnits":1}}`,
+		`{"TableName":"Test","GlobalSecondaryIndexUpdates":[{"Delete":{"IndexName":"ByName"}}]}`,
+		`{"TableName":"Test","AttributeDefinitions":[{"AttributeName":"Email","AttributeType":"S"}],"GlobalSecondaryIndexUpdates":[{"Create":{"IndexName":"ByEmail","KeySchema":[{"AttributeName":"Email","KeyType":"HASH"}],"Projection":{"NonKeyAttributes":null,"ProjectionType":"KEYS_ONLY"}}}]}`,
+		`{"TableName":"Test","BillingMode":"PROVISIONED","ProvisionedThroughput":{"ReadCapacityUnits":2,"WriteCapacityUnits":2},"GlobalSecondaryIndexUpdates":[{"Update":{"IndexName":"ByEmail","ProvisionedThroughput":{"ReadCapacityUnits":1,"WriteCapacityUnits":1}}}],"StreamSpecification":{"StreamEnabled":false}}`,
+		`{"TableName":"Test","GlobalSecondaryIndexUpdates":[{"Update":{"IndexName":"ByName","ProvisionedThroughput":{"ReadCapacityUnits":3,"WriteCapacityUnits":3}}}]}`,
+	}
+	if !reflect.DeepEqual(bodies, want) {
+		for i := range want {
+			t.Error("want", want[i])
+			if i < len(bodies) {
+				t.Error("got ", bodies[i])
+			}
+		}
+	}
+}
+
+func TestTableDesc(t *testing.T) {
+	var desc TableDesc
+	err := json.Unmarshal([]byte(`{
+		"TableArn":"arn:aws:dynamodb:local:0:table/Test",
+		"TableId":"id",
+		"TableName":"Test",
+		"BillingModeSummary":{"BillingMode":"PAY_PER_REQUEST"},
+		"StreamSpecification":{"StreamEnabled":true,"StreamViewType":"NEW_IMAGE"},
+		"LatestStreamArn":"arn:aws:dynamodb:local:0:table/Test/stream/1",
+		"SSEDescription":{"Status":"ENABLED","SSEType":"KMS"},
+		"GlobalSecondaryIndexes":[{"IndexName":"ByName","IndexArn":"arn:gsi","Backfilling":true}],
+		"LocalSecondaryIndexes":[{"IndexName":"ByTime","IndexArn":"arn:lsi"}]
+	}`), &desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if desc.TableArn == "" || desc.TableId != "id" || desc.BillingMode() != BillingPayPerRequest || desc.StreamSpecification.StreamViewType != StreamNewImage ||
+		desc.LatestStreamArn == "" || desc.SSEDescription.SSEType != "KMS" || desc.GlobalSecondaryIndexes[0].IndexArn != "arn:gsi" ||
+		!desc.GlobalSecondaryIndexes[0].Backfilling || desc.LocalSecondaryIndexes[0].IndexArn != "arn:lsi" {
+		t.Error("got", desc)
+	}
+	if (&TableDesc{}).BillingMode() != BillingProvisioned {
+		t.Error("want provisioned billing by default")
+	}
+}
+
+func TestModelIndexes(t *testing.T) {
+	globals, locals, err := modelIndexes(&indexedItem{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []GlobalIndex{
+		{Index: Index{"ByEmail", []KeyItem{{"email", "HASH"}}, Projection{nil, ProjectKeysOnly}}},
+		{Index: Index{"ByTeam", []KeyItem{{"team", "HASH"}, {"joined", "RANGE"}}, Projection{nil, ProjectAll}}},
+	}
+	if !reflect.DeepEqual(globals, want) {
+		t.Error("want", want)
+		t.Error("got ", globals)
+	}
+	wantLocal := []Index{{"ByJoined", []KeyItem{{"id", "HASH"}, {"joined", "RANGE"}}, Projection{[]string{"name"}, ProjectInclude}}}
+	if !reflect.DeepEqual(locals, wantLocal) {
+		t.Error("want", wantLocal)
+		t.Error("got ", locals)
+	}
+
+	for _, item := range []interface{}{
+		&struct {
+			A string `ddb:"a,gsi=ByA"`
+		}{},
+		&struct {
+			A string `ddb:"a,gsi=ByA:RANGE"`
+		}{},
+		&struct {
+			A string `ddb:"a,HASH"`
+			B string `ddb:"b,lsi=ByB:HASH"`
+		}{},
+		&struct {
+			A string `ddb:"a,gsi=ByA:HASH:KEYS_ONLY"`
+			B string `ddb:"b,project=ByA"`
+		}{},
+		&struct {
+			A string `ddb:"a,gsi=ByA:HASH"`
+			B string `ddb:"b,project=ByB"`
+		}{},
+	} {
+		if _, _, err := modelIndexes(item); err == nil {
+			t.Errorf("want error for %T", item)
+		}
+	}
+}
+
+func TestIndexFromTags(t *testing.T) {
+	var body string
+	server, client := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body = string(b)
+		w.Write([]byte(`{}`))
+	})
+	defer server.Close()
+
+	ctx := context.Background()
+	client.CreateTableWith(ctx, "Users", &indexedItem{}, &TableOptions{BillingMode: BillingPayPerRequest})
+	var req struct {
+		AttributeDefinitions   []AttributeDefinition
+		GlobalSecondaryIndexes []Index
+		LocalSecondaryIndexes  []Index
+	}
+	json.Unmarshal([]byte(body), &req)
+	if len(req.AttributeDefinitions) != 4 || len(req.GlobalSecondaryIndexes) != 2 || len(req.LocalSecondaryIndexes) != 1 {
+		t.Error("got", body)
+	}
+
+	table := client.Table("Users")
+	table.Query().Index("ByTeam").Match(&indexedItem{Team: "chat"}).Items(ctx, false)
+	if !strings.Contains(body, `"IndexName":"ByTeam"`) || !strings.Contains(body, `"team":{"ComparisonOperator":"EQ", "AttributeValueList":[{"S":"chat"}]}`) {
+		t.Error("got", body)
+	}
+	if _, err := table.Query().Index("ByName").Match(&indexedItem{Name: "x"}).Items(ctx, false); err == nil {
+		t.Error("want error for an index missing from the model")
+	}
+
+	// without a schema, the index is left to DynamoDB
+	body = ""
+	_, err := client.Table("Unknown").Query().Index("ByName").Where("name", EQ, []byte(`{"S":"x"}`)).Items(ctx, false)
+	if err != nil || !strings.Contains(body, `"IndexName":"ByName"`) {
+		t.Error("got", body, err)
+	}
+}
@@ -0,0 +1,57 @@
+// Public Domain (-) 2012-2013 The Go DynamoDB Authors.
+// See the Go DynamoDB UNLICENSE file for details.
+
+package dynamodb
+
+import (
+	"encoding/json"
+
+	"golang.org/x/net/context"
+)
+
+// TagResource adds tags to the resource arn, e.g. the TableArn
+// of a table, replacing the values of those it already has.
+// Tags can also be applied as tables are created, see
+// TableOptions.
+func (c *Client) TagResource(ctx context.Context, arn string, tags []Tag) error {
+	_, err := c.Call(ctx, "TagResource", Map{
+		"ResourceArn": arn,
+		"Tags":        tags,
+	})
+	return err
+}
+
+// UntagResource removes the tags with keys from the resource
+// arn.
+func (c *Client) UntagResource(ctx context.Context, arn string, keys []string) error {
+	_, err := c.Call(ctx, "UntagResource", Map{
+		"ResourceArn": arn,
+		"TagKeys":     keys,
+	})
+	return err
+}
+
+// ListTagsOfResource lists the tags of the resource arn,
+// following NextToken across pages.
+func (c *Client) ListTagsOfResource(ctx context.Context, arn string) ([]Tag, error) {
+	var tags []Tag
+	args := Map{"ResourceArn": arn}
+	for {
+		payload, err := c.Call(ctx, "ListTagsOfResource", args)
+		if err != nil {
+			return nil, err
+		}
+		var resp struct {
+			Tags      []Tag
+			NextToken string
+		}
+		if err := json.Unmarshal(payload, &resp); err != nil {
+			return nil, err
+		}
+		tags = append(tags, resp.Tags...)
+		if resp.NextToken == "" {
+			return tags, nil
+		}
+		args["NextToken"] = resp.NextToken
+	}
+}
@@ -0,0 +1,53 @@
+package dynamodb
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestTags(t *testing.T) {
+	var requests []map[string]interface{}
+	server, client := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var args map[string]interface{}
+		json.Unmarshal(body, &args)
+		requests = append(requests, args)
+		switch r.Header.Get("X-Amz-Target") {
+		case "DynamoDB_20120810.ListTagsOfResource":
+			if args["NextToken"] == nil {
+				w.Write([]byte(`{"Tags":[{"Key":"env","Value":"prod"}],"NextToken":"1"}`))
+			} else {
+				w.Write([]byte(`{"Tags":[{"Key":"team","Value":"core"}]}`))
+			}
+		default:
+			w.Write([]byte(`{}`))
+		}
+	})
+	defer server.Close()
+	ctx := context.Background()
+	arn := "arn:aws:dynamodb:local:0:table/Test"
+
+	tags, err := client.ListTagsOfResource(ctx, arn)
+	want := []Tag{{"env", "prod"}, {"team", "core"}}
+	if err != nil || !reflect.DeepEqual(tags, want) {
+		t.Error("want", want)
+		t.Error("got ", tags, err)
+	}
+
+	requests = nil
+	client.TagResource(ctx, arn, []Tag{{"cost", "42"}})
+	client.UntagResource(ctx, arn, []string{"env"})
+	wantRequests := []map[string]interface{}{
+		{"ResourceArn": arn, "Tags": []interface{}{map[string]interface{}{"Key": "cost", "Value": "42"}}},
+		{"ResourceArn": arn, "TagKeys": []interface{}{"env"}},
+	}
+	if !reflect.DeepEqual(requests, wantRequests) {
+		t.Error("want", wantRequests)
+		t.Error("got ", requests)
+	}
+}
@@ -0,0 +1,142 @@
+// Public Domain (-) 2012-2013 The Go DynamoDB Authors.
+// See the Go DynamoDB UNLICENSE file for details.
+
+package dynamodb
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Tracer starts a Span for every call made by a Client. The
+// otel subpackage adapts OpenTelemetry tracers to it and
+// MemoryTracer records spans for tests.
+type Tracer interface {
+	// StartSpan starts a span named name as a child of any
+	// span carried by ctx and returns a context carrying it.
+	StartSpan(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span represents a single call to DynamoDB, including all of
+// its retries.
+type Span interface {
+	SetAttribute(key string, value interface{})
+
+	// End finishes the span, marking it as failed if err is
+	// not nil.
+	End(err error)
+}
+
+// Attributes set on every Span. They follow the OpenTelemetry
+// semantic conventions for DynamoDB where these exist.
+const (
+	AttrSystem           = "rpc.system"
+	AttrService          = "rpc.service"
+	AttrMethod           = "rpc.method"
+	AttrTableNames       = "aws.dynamodb.table_names"
+	AttrAttempts         = "aws.dynamodb.attempts"
+	AttrThrottles        = "aws.dynamodb.throttles"
+	AttrConsumedCapacity = "aws.dynamodb.consumed_capacity_units"
+	AttrErrorType        = "error.type"
+)
+
+func endSpan(span Span, info *CallInfo) {
+	span.SetAttribute(AttrSystem, "aws-api")
+	span.SetAttribute(AttrService, "DynamoDB")
+	span.SetAttribute(AttrMethod, info.Operation)
+	if len(info.Tables) > 0 {
+		span.SetAttribute(AttrTableNames, info.Tables)
+	}
+	span.SetAttribute(AttrAttempts, info.Attempts)
+	span.SetAttribute(AttrThrottles, info.Throttles)
+	if len(info.ConsumedCapacity) > 0 {
+		units := 0.0
+		for _, cc := range info.ConsumedCapacity {
+			units += cc.CapacityUnits
+		}
+		span.SetAttribute(AttrConsumedCapacity, units)
+	}
+	if info.Err != nil {
+		span.SetAttribute(AttrErrorType, info.ErrorType)
+	}
+	span.End(info.Err)
+}
+
+// MemoryTracer is a Tracer which keeps every span it starts in
+// memory so that tests can inspect them.
+type MemoryTracer struct {
+	mu    sync.Mutex
+	spans []*MemorySpan
+}
+
+// MemorySpan is a Span recorded by a MemoryTracer.
+type MemorySpan struct {
+	Name       string
+	Parent     *MemorySpan
+	Attributes map[string]interface{}
+	Start      time.Time
+	Finish     time.Time
+	Err        error
+	Ended      bool
+
+	mu *sync.Mutex
+}
+
+type memorySpanKey struct{}
+
+// StartSpan satisfies the Tracer interface.
+func (t *MemoryTracer) StartSpan(ctx context.Context, name string) (context.Context, Span) {
+	parent, _ := ctx.Value(memorySpanKey{}).(*MemorySpan)
+	span := &MemorySpan{
+		Name:       name,
+		Parent:     parent,
+		Attributes: map[string]interface{}{},
+		Start:      time.Now(),
+		mu:         &t.mu,
+	}
+	t.mu.Lock()
+	t.spans = append(t.spans, span)
+	t.mu.Unlock()
+	return context.WithValue(ctx, memorySpanKey{}, span), span
+}
+
+// Spans returns the spans started so far.
+func (t *MemoryTracer) Spans() []*MemorySpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	spans := make([]*MemorySpan, len(t.spans))
+	copy(spans, t.spans)
+	return spans
+}
+
+// Reset forgets all the spans started so far.
+func (t *MemoryTracer) Reset() {
+	t.mu.Lock()
+	t.spans = nil
+	t.mu.Unlock()
+}
+
+// SetAttribute satisfies the Span interface.
+func (s *MemorySpan) SetAttribute(key string, value interface{}) {
+	s.mu.Lock()
+	s.Attributes[key] = value
+	s.mu.Unlock()
+}
+
+// End satisfies the Span interface.
+func (s *MemorySpan) End(err error) {
+	s.mu.Lock()
+	s.Finish = time.Now()
+	s.Err = err
+	s.Ended = true
+	s.mu.Unlock()
+}
+
+// MemorySpanFromContext returns the MemorySpan carried by
+// ctx, if any.
+func MemorySpanFromContext(ctx context.Context) *MemorySpan {
+	span, _ := ctx.Value(memorySpanKey{}).(*MemorySpan)
+	return span
+}
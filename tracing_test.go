@@ -0,0 +1,64 @@
+package dynamodb
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestTracer(t *testing.T) {
+	calls := 0
+	server, client := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(400)
+			w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#ProvisionedThroughputExceededException","message":"slow down"}`))
+			return
+		}
+		w.Write([]byte(`{"ConsumedCapacity":{"TableName":"Test","CapacityUnits":1}}`))
+	})
+	defer server.Close()
+
+	tracer := &MemoryTracer{}
+	client.Tracer = tracer
+	client.ErrorLog = nil
+	client.Use(func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) ([]byte, error) {
+			if MemorySpanFromContext(ctx) == nil {
+				t.Error("context does not carry the span")
+			}
+			return next(ctx, req)
+		}
+	})
+
+	parent, root := tracer.StartSpan(context.Background(), "parent")
+	err := client.Table("Test").Put(parent, &MyItem{Name: "Tom"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root.End(nil)
+
+	spans := tracer.Spans()
+	if len(spans) != 2 {
+		t.Fatal("got", len(spans), "spans")
+	}
+	span := spans[1]
+	if span.Name != "DynamoDB.PutItem" || span.Parent != spans[0] || !span.Ended || span.Err != nil {
+		t.Error("got", span)
+	}
+	want := map[string]interface{}{
+		AttrSystem:           "aws-api",
+		AttrService:          "DynamoDB",
+		AttrMethod:           "PutItem",
+		AttrTableNames:       []string{"Test"},
+		AttrAttempts:         2,
+		AttrThrottles:        1,
+		AttrConsumedCapacity: 1.0,
+	}
+	if !reflect.DeepEqual(span.Attributes, want) {
+		t.Error("want", want)
+		t.Error("got ", span.Attributes)
+	}
+}
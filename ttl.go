@@ -0,0 +1,114 @@
+// Public Domain (-) 2012-2013 The Go DynamoDB Authors.
+// See the Go DynamoDB UNLICENSE file for details.
+
+package dynamodb
+
+import (
+	"encoding/json"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Statuses of time to live.
+const (
+	TimeToLiveEnabled   = "ENABLED"
+	TimeToLiveEnabling  = "ENABLING"
+	TimeToLiveDisabled  = "DISABLED"
+	TimeToLiveDisabling = "DISABLING"
+)
+
+// TimeToLiveSpecification enables or disables the expiry of the
+// items of a table by the epoch seconds in AttributeName.
+type TimeToLiveSpecification struct {
+	Enabled       bool
+	AttributeName string
+}
+
+// TimeToLiveDescription is the time to live of a table.
+type TimeToLiveDescription struct {
+	TimeToLiveStatus string
+	AttributeName    string `json:",omitempty"`
+}
+
+// Enabled reports whether items expire, or are about to.
+func (d *TimeToLiveDescription) Enabled() bool {
+	return d.TimeToLiveStatus == TimeToLiveEnabled || d.TimeToLiveStatus == TimeToLiveEnabling
+}
+
+// UpdateTimeToLive enables or disables time to live on the
+// table called name. DynamoDB only allows one change of the
+// setting per hour.
+func (c *Client) UpdateTimeToLive(
+	ctx context.Context,
+	name string,
+	spec TimeToLiveSpecification,
+) (*TimeToLiveSpecification, error) {
+	payload, err := c.Call(ctx, "UpdateTimeToLive", Map{
+		"TableName":               name,
+		"TimeToLiveSpecification": spec,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		TimeToLiveSpecification TimeToLiveSpecification
+	}
+	err = json.Unmarshal(payload, &resp)
+	return &resp.TimeToLiveSpecification, err
+}
+
+// DescribeTimeToLive describes the time to live of the table
+// called name.
+func (c *Client) DescribeTimeToLive(
+	ctx context.Context,
+	name string,
+) (*TimeToLiveDescription, error) {
+	payload, err := c.Call(ctx, "DescribeTimeToLive", Map{"TableName": name})
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		TimeToLiveDescription TimeToLiveDescription
+	}
+	err = json.Unmarshal(payload, &resp)
+	return &resp.TimeToLiveDescription, err
+}
+
+// EncodeTTL encodes the expiry time t as the epoch seconds
+// stored by fields tagged with ttl. The zero time encodes as 0,
+// which DynamoDB never expires.
+func EncodeTTL(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// EncodeTTLDuration encodes the expiry d from now like
+// EncodeTTL, with 0 for no expiry.
+func EncodeTTLDuration(d time.Duration) int64 {
+	if d == 0 {
+		return 0
+	}
+	return time.Now().Add(d).Unix()
+}
+
+// DecodeTTL decodes epoch seconds encoded by EncodeTTL, in UTC
+// like other times.
+func DecodeTTL(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(n, 0).UTC()
+}
+
+// DecodeTTLDuration decodes epoch seconds encoded by
+// EncodeTTLDuration into the time left until the expiry, which
+// is negative once it passed.
+func DecodeTTLDuration(n int64) time.Duration {
+	if n == 0 {
+		return 0
+	}
+	return time.Unix(n, 0).Sub(time.Now())
+}
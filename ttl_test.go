@@ -0,0 +1,99 @@
+package dynamodb
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+type session struct {
+	Token   string        `ddb:"token,HASH"`
+	Expires time.Time     `ddb:"expires,ttl"`
+	Renew   time.Duration `ddb:"renew,ttl"`
+}
+
+func TestTTLField(t *testing.T) {
+	in := &session{Token: "t", Expires: time.Unix(1700000000, 0), Renew: time.Hour}
+	data, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"expires":{"N":"1700000000"}`) {
+		t.Error("got", string(data))
+	}
+	out := &session{}
+	if err := Unmarshal(data, out); err != nil {
+		t.Fatal(err)
+	}
+	if !out.Expires.Equal(in.Expires) || out.Renew <= 59*time.Minute || out.Renew > time.Hour {
+		t.Error("got", out)
+	}
+
+	// zero values never expire
+	data, _ = Marshal(&session{Token: "t"})
+	out = &session{}
+	Unmarshal(data, out)
+	if !strings.Contains(string(data), `"expires":{"N":"0"}`) || !out.Expires.IsZero() || out.Renew != 0 {
+		t.Error("got", string(data), out)
+	}
+
+	def, err := DefineTable("Sessions", &session{}, nil)
+	if err != nil || def.TimeToLiveAttribute != "expires" {
+		t.Error("got", def, err)
+	}
+}
+
+func TestTimeToLive(t *testing.T) {
+	var targets []string
+	var update map[string]interface{}
+	server, client := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		target := r.Header.Get("X-Amz-Target")[len("DynamoDB_20120810."):]
+		targets = append(targets, target)
+		body, _ := ioutil.ReadAll(r.Body)
+		switch target {
+		case "CreateTable":
+			w.Write([]byte(`{"TableDescription":{"TableName":"Sessions","TableStatus":"CREATING"}}`))
+		case "DescribeTable":
+			w.Write([]byte(`{"Table":{"TableName":"Sessions","TableStatus":"ACTIVE"}}`))
+		case "UpdateTimeToLive":
+			json.Unmarshal(body, &update)
+			w.Write([]byte(`{"TimeToLiveSpecification":{"Enabled":true,"AttributeName":"expires"}}`))
+		case "DescribeTimeToLive":
+			w.Write([]byte(`{"TimeToLiveDescription":{"TimeToLiveStatus":"ENABLING","AttributeName":"expires"}}`))
+		}
+	})
+	defer server.Close()
+
+	ctx := context.Background()
+	if _, err := client.CreateTableWith(ctx, "Sessions", &session{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(targets, []string{"CreateTable"}) {
+		t.Error("want time to live left disabled, got", targets)
+	}
+
+	// enabling time to live waits for the table
+	targets = nil
+	if _, err := client.CreateTableWith(ctx, "Sessions", &session{}, &TableOptions{EnableTimeToLive: true}); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"TableName":               "Sessions",
+		"TimeToLiveSpecification": map[string]interface{}{"Enabled": true, "AttributeName": "expires"},
+	}
+	if !reflect.DeepEqual(targets, []string{"CreateTable", "DescribeTable", "UpdateTimeToLive"}) || !reflect.DeepEqual(update, want) {
+		t.Error("want", want)
+		t.Error("got ", targets, update)
+	}
+
+	desc, err := client.DescribeTimeToLive(ctx, "Sessions")
+	if err != nil || !desc.Enabled() || desc.AttributeName != "expires" {
+		t.Error("got", desc, err)
+	}
+}
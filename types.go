@@ -1,5 +1,7 @@
 package dynamodb
 
+import "encoding/json"
+
 type ProvisionedThroughput struct {
 	ReadCapacityUnits  int
 	WriteCapacityUnits int
@@ -34,11 +36,86 @@ type GlobalIndex struct {
 	ProvisionedThroughput ProvisionedThroughput
 }
 
+// Stream view types of StreamSpecification.
+const (
+	StreamKeysOnly        = "KEYS_ONLY"
+	StreamNewImage        = "NEW_IMAGE"
+	StreamOldImage        = "OLD_IMAGE"
+	StreamNewAndOldImages = "NEW_AND_OLD_IMAGES"
+)
+
+type StreamSpecification struct {
+	StreamEnabled  bool
+	StreamViewType string `json:",omitempty"`
+}
+
+type SSESpecification struct {
+	Enabled        bool
+	SSEType        string `json:",omitempty"`
+	KMSMasterKeyId string `json:",omitempty"`
+}
+
+type Tag struct {
+	Key   string
+	Value string
+}
+
+// GlobalIndexUpdate creates, updates or deletes a global index
+// in UpdateTable. Only one of its fields is set, see
+// CreateIndex, UpdateIndex and DeleteIndex.
 type GlobalIndexUpdate struct {
-	Update struct {
-		IndexName             string
-		ProvisionedThroughput ProvisionedThroughput
+	Create *GlobalIndexCreate
+	Update GlobalIndexChange
+	Delete *GlobalIndexDelete
+}
+
+// MarshalJSON leaves out the fields of u which aren't set.
+func (u GlobalIndexUpdate) MarshalJSON() ([]byte, error) {
+	var update struct {
+		Create *GlobalIndexCreate `json:",omitempty"`
+		Update *GlobalIndexChange `json:",omitempty"`
+		Delete *GlobalIndexDelete `json:",omitempty"`
+	}
+	update.Create, update.Delete = u.Create, u.Delete
+	if u.Update != (GlobalIndexChange{}) {
+		update.Update = &u.Update
 	}
+	return json.Marshal(update)
+}
+
+type GlobalIndexCreate struct {
+	Index
+	ProvisionedThroughput *ProvisionedThroughput `json:",omitempty"`
+}
+
+type GlobalIndexChange struct {
+	IndexName             string
+	ProvisionedThroughput ProvisionedThroughput
+}
+
+type GlobalIndexDelete struct {
+	IndexName string
+}
+
+// CreateIndex creates index, with its throughput unless it is
+// zero, as for tables billed per request.
+func CreateIndex(index GlobalIndex) GlobalIndexUpdate {
+	create := &GlobalIndexCreate{Index: index.Index}
+	if index.ProvisionedThroughput != (ProvisionedThroughput{}) {
+		throughput := index.ProvisionedThroughput
+		create.ProvisionedThroughput = &throughput
+	}
+	return GlobalIndexUpdate{Create: create}
+}
+
+// UpdateIndex changes the throughput of the index called name.
+func UpdateIndex(name string, throughput ProvisionedThroughput) GlobalIndexUpdate {
+	return GlobalIndexUpdate{Update: GlobalIndexChange{name, throughput}}
+}
+
+// DeleteIndex deletes the index called name.
+func DeleteIndex(name string) GlobalIndexUpdate {
+	return GlobalIndexUpdate{Delete: &GlobalIndexDelete{name}}
 }
 
 type TablesList struct {
@@ -46,10 +123,17 @@ type TablesList struct {
 	TableNames             []string
 }
 
+// TableUpdate is the payload of UpdateTable. Only the fields
+// being changed are set.
 type TableUpdate struct {
-	GlobalSecondaryIndexUpdates []GlobalIndexUpdate
-	ProvisionedThroughput       ProvisionedThroughput
 	TableName                   string
+	AttributeDefinitions        []AttributeDefinition  `json:",omitempty"`
+	BillingMode                 string                 `json:",omitempty"`
+	ProvisionedThroughput       *ProvisionedThroughput `json:",omitempty"`
+	GlobalSecondaryIndexUpdates []GlobalIndexUpdate    `json:",omitempty"`
+	StreamSpecification         *StreamSpecification   `json:",omitempty"`
+	SSESpecification            *SSESpecification      `json:",omitempty"`
+	TableClass                  string                 `json:",omitempty"`
 }
 
 type TableCreate struct {
@@ -63,26 +147,66 @@ type TableCreate struct {
 
 type TableDesc struct {
 	AttributeDefinitions   []AttributeDefinition
+	BillingModeSummary     *BillingModeSummary
 	CreationDateTime       float64
-	GlobalSecondaryIndexes []struct {
-		GlobalIndex
-		IndexSizeBytes int
-		IndexStatus    string
-		ItemCount      int
-		// is this king of overriding legal? See GlobalSecondaryIndex
-		ProvisionedThroughput ProvisionedThroughputDesc
-	}
-	ItemCount             int
-	KeySchema             []KeyItem
-	LocalSecondaryIndexes []struct {
-		Index
-		IndexSizeBytes int
-		ItemCount      int
+	GlobalSecondaryIndexes []GlobalIndexDesc
+	ItemCount              int
+	KeySchema              []KeyItem
+	LatestStreamArn        string
+	LatestStreamLabel      string
+	LocalSecondaryIndexes  []LocalIndexDesc
+	ProvisionedThroughput  ProvisionedThroughputDesc
+	SSEDescription         *SSEDescription
+	StreamSpecification    *StreamSpecification
+	TableArn               string
+	TableClassSummary      *TableClassSummary
+	TableId                string
+	TableName              string
+	TableSizeBytes         int
+	TableStatus            string
+}
+
+// BillingMode returns the billing mode of the table, which is
+// BillingProvisioned unless summarized otherwise.
+func (d *TableDesc) BillingMode() string {
+	if d.BillingModeSummary == nil || d.BillingModeSummary.BillingMode == "" {
+		return BillingProvisioned
 	}
+	return d.BillingModeSummary.BillingMode
+}
+
+type GlobalIndexDesc struct {
+	GlobalIndex
+	Backfilling    bool
+	IndexArn       string
+	IndexSizeBytes int
+	IndexStatus    string
+	ItemCount      int
+	// is this king of overriding legal? See GlobalSecondaryIndex
 	ProvisionedThroughput ProvisionedThroughputDesc
-	TableName             string
-	TableSizeBytes        int
-	TableStatus           string
+}
+
+type LocalIndexDesc struct {
+	Index
+	IndexArn       string
+	IndexSizeBytes int
+	ItemCount      int
+}
+
+type BillingModeSummary struct {
+	BillingMode                       string
+	LastUpdateToPayPerRequestDateTime float64
+}
+
+type SSEDescription struct {
+	Status          string
+	SSEType         string
+	KMSMasterKeyArn string
+}
+
+type TableClassSummary struct {
+	TableClass         string
+	LastUpdateDateTime float64
 }
 
 type TableDescWrapper struct {
@@ -97,4 +221,37 @@ type ResponseItem map[string]map[string]interface{}
 
 type GetItem struct {
 	Item ResponseItem
+	ItemResult
+}
+
+type QueryResult struct {
+	ConsumedCapacity *ConsumedCapacity
+	Count            int
+	Items            []ResponseItem
+	LastEvaluatedKey ResponseItem
+	ScannedCount     int
+}
+
+type ItemCollectionMetrics struct {
+	ItemCollectionKey   ResponseItem
+	SizeEstimateRangeGB []float64
+}
+
+type ItemResult struct {
+	ConsumedCapacity      *ConsumedCapacity
+	ItemCollectionMetrics *ItemCollectionMetrics
+}
+
+type Capacity struct {
+	CapacityUnits      float64
+	ReadCapacityUnits  float64
+	WriteCapacityUnits float64
+}
+
+type ConsumedCapacity struct {
+	Capacity
+	GlobalSecondaryIndexes map[string]Capacity
+	LocalSecondaryIndexes  map[string]Capacity
+	Table                  *Capacity
+	TableName              string
 }
@@ -0,0 +1,107 @@
+// Public Domain (-) 2012-2013 The Go DynamoDB Authors.
+// See the Go DynamoDB UNLICENSE file for details.
+
+package dynamodb
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// The interval between two polls of a waiter starts at
+// waitIntervalMin and doubles up to waitIntervalMax.
+const (
+	waitIntervalMin = 50 * time.Millisecond
+	waitIntervalMax = 5 * time.Second
+)
+
+// waitNotFoundMax is the number of polls a table which isn't
+// found is waited for, as DescribeTable may not see a table
+// created just before.
+const waitNotFoundMax = 5
+
+// WaitUntilActive polls DescribeTable until the table called
+// name and all of its global secondary indexes are ACTIVE,
+// e.g. after CreateTable or UpdateTable, and returns the last
+// description. A table which isn't found is only waited for
+// during the first few polls, after which the
+// ResourceNotFoundException is returned. It gives up with the
+// context's error once ctx is done.
+func (c *Client) WaitUntilActive(ctx context.Context, name string) (*TableDesc, error) {
+	var desc *TableDesc
+	polls := 0
+	err := c.wait(ctx, func() (bool, error) {
+		var err error
+		desc, err = c.DescribeTable(quiet(ctx), name)
+		if polls++; notFound(err) && polls < waitNotFoundMax {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return active(desc), nil
+	})
+	return desc, err
+}
+
+// WaitUntilDeleted polls DescribeTable until the table called
+// name no longer exists, e.g. after DeleteTable. It gives up
+// with the context's error once ctx is done.
+func (c *Client) WaitUntilDeleted(ctx context.Context, name string) error {
+	return c.wait(ctx, func() (bool, error) {
+		_, err := c.DescribeTable(quiet(ctx), name)
+		if notFound(err) {
+			return true, nil
+		}
+		return false, err
+	})
+}
+
+// wait calls done, backing off between calls, until it
+// reports true or fails, or ctx is done.
+func (c *Client) wait(ctx context.Context, done func() (bool, error)) error {
+	interval := waitIntervalMin
+	for {
+		ok, err := done()
+		if ok || err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+		if interval *= 2; interval > waitIntervalMax {
+			interval = waitIntervalMax
+		}
+	}
+}
+
+// active reports whether the table and its indexes are ACTIVE.
+func active(desc *TableDesc) bool {
+	if desc.TableStatus != "ACTIVE" {
+		return false
+	}
+	for _, index := range desc.GlobalSecondaryIndexes {
+		if index.IndexStatus != "ACTIVE" {
+			return false
+		}
+	}
+	return true
+}
+
+type quietKey struct{}
+
+// quiet returns a context whose calls don't log their errors to
+// ErrorLog, for responses which are expected.
+func quiet(ctx context.Context) context.Context {
+	return context.WithValue(ctx, quietKey{}, true)
+}
+
+// notFound reports whether err is DynamoDB's response for a
+// table which doesn't exist.
+func notFound(err error) bool {
+	e, ok := err.(Error)
+	return ok && e.Type() == "ResourceNotFoundException"
+}
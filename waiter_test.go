@@ -0,0 +1,91 @@
+package dynamodb
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestWaitUntilActive(t *testing.T) {
+	responses := []string{
+		`{"__type":"com.amazonaws.dynamodb.v20120810#ResourceNotFoundException","message":"not found"}`,
+		`{"Table":{"TableName":"Test","TableStatus":"CREATING"}}`,
+		`{"Table":{"TableName":"Test","TableStatus":"ACTIVE","GlobalSecondaryIndexes":[{"IndexName":"A","IndexStatus":"ACTIVE"},{"IndexName":"B","IndexStatus":"CREATING"}]}}`,
+		`{"Table":{"TableName":"Test","TableStatus":"ACTIVE","GlobalSecondaryIndexes":[{"IndexName":"A","IndexStatus":"ACTIVE"},{"IndexName":"B","IndexStatus":"ACTIVE"}]}}`,
+	}
+	calls := 0
+	server, client := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		resp := responses[calls]
+		calls++
+		if calls == 1 {
+			w.WriteHeader(400)
+		}
+		w.Write([]byte(resp))
+	})
+	defer server.Close()
+	logged := &bytes.Buffer{}
+	client.ErrorLog = log.New(logged, "", 0)
+
+	desc, err := client.WaitUntilActive(context.Background(), "Test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 4 || desc.GlobalSecondaryIndexes[1].IndexStatus != "ACTIVE" {
+		t.Error("got", calls, desc)
+	}
+	if logged.Len() != 0 {
+		t.Error("logged", logged.String())
+	}
+}
+
+func TestWaitUntilActiveNotFound(t *testing.T) {
+	calls := 0
+	server, client := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(400)
+		fmt.Fprint(w, `{"__type":"com.amazonaws.dynamodb.v20120810#ResourceNotFoundException","message":"not found"}`)
+	})
+	defer server.Close()
+	logged := &bytes.Buffer{}
+	client.ErrorLog = log.New(logged, "", 0)
+
+	_, err := client.WaitUntilActive(context.Background(), "Missing")
+	if !notFound(err) || calls != waitNotFoundMax {
+		t.Error("got", calls, err)
+	}
+	if logged.Len() != 0 {
+		t.Error("logged", logged.String())
+	}
+}
+
+func TestWaitUntilDeleted(t *testing.T) {
+	calls := 0
+	server, client := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			fmt.Fprint(w, `{"Table":{"TableName":"Test","TableStatus":"DELETING"}}`)
+			return
+		}
+		w.WriteHeader(400)
+		fmt.Fprint(w, `{"__type":"com.amazonaws.dynamodb.v20120810#ResourceNotFoundException","message":"not found"}`)
+	})
+	defer server.Close()
+	client.ErrorLog = nil
+
+	if err := client.WaitUntilDeleted(context.Background(), "Test"); err != nil || calls != 3 {
+		t.Error("got", calls, err)
+	}
+
+	// waiting stops with the context
+	calls = 0
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if _, err := client.WaitUntilActive(ctx, "Test"); err != context.DeadlineExceeded {
+		t.Error("got", err)
+	}
+}